@@ -5,9 +5,14 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"strconv"
+
+	// Embed the timezone database as the container image does not ship one.
+	_ "time/tzdata"
 
-	"github.com/deepmap/oapi-codegen/pkg/securityprovider"
 	"github.com/oliverbenns/klaviyo-report/generated/klaviyo"
+	"github.com/oliverbenns/klaviyo-report/internal/accounts"
+	"github.com/oliverbenns/klaviyo-report/internal/klaviyoclient"
 	"github.com/oliverbenns/klaviyo-report/internal/server/api"
 	redis "github.com/redis/go-redis/v9"
 )
@@ -29,9 +34,9 @@ func run() error {
 		return fmt.Errorf("error connecting to redis: %w", err)
 	}
 
-	klaviyoClient, err := createKlaviyoClient()
+	accountRegistry, err := createAccountRegistry(ctx, redisClient, logger)
 	if err != nil {
-		return fmt.Errorf("error creating klaviyo client: %w", err)
+		return fmt.Errorf("error creating account registry: %w", err)
 	}
 
 	apiKey := os.Getenv("API_KEY")
@@ -39,12 +44,23 @@ func run() error {
 		return fmt.Errorf("API_KEY not set")
 	}
 
+	klaviyoConcurrency := 0
+	klaviyoConcurrencyStr := os.Getenv("KLAVIYO_CONCURRENCY")
+	if klaviyoConcurrencyStr != "" {
+		klaviyoConcurrency, err = strconv.Atoi(klaviyoConcurrencyStr)
+		if err != nil {
+			return fmt.Errorf("invalid KLAVIYO_CONCURRENCY: %w", err)
+		}
+	}
+
 	svc := api.Service{
-		Port:          8080,
-		RedisClient:   redisClient,
-		Logger:        logger,
-		ApiKey:        apiKey,
-		KlaviyoClient: klaviyoClient,
+		Port:               8080,
+		RedisClient:        redisClient,
+		Logger:             logger,
+		ApiKey:             apiKey,
+		Accounts:           accountRegistry,
+		KlaviyoClients:     &klaviyoclient.Pool{},
+		KlaviyoConcurrency: klaviyoConcurrency,
 	}
 
 	err = svc.Run(ctx)
@@ -55,22 +71,61 @@ func run() error {
 	return nil
 }
 
-func createKlaviyoClient() (*klaviyo.ClientWithResponses, error) {
+// createAccountRegistry loads the Klaviyo accounts from, in order of
+// precedence, a JSON file (KLAVIYO_ACCOUNTS_FILE), a Redis hash
+// (KLAVIYO_ACCOUNTS_REDIS_KEY) or a single private key (KLAVIYO_API_KEY).
+// KLAVIYO_CONVERSION_METRIC only applies to the single private key, file and
+// Redis accounts set conversion_metric per account.
+func createAccountRegistry(ctx context.Context, redisClient *redis.Client, logger *slog.Logger) (accounts.Registry, error) {
+	accountsFile := os.Getenv("KLAVIYO_ACCOUNTS_FILE")
+	accountsRedisKey := os.Getenv("KLAVIYO_ACCOUNTS_REDIS_KEY")
+	if (accountsFile != "" || accountsRedisKey != "") && os.Getenv("KLAVIYO_CONVERSION_METRIC") != "" {
+		logger.Warn("KLAVIYO_CONVERSION_METRIC has no effect with KLAVIYO_ACCOUNTS_FILE or KLAVIYO_ACCOUNTS_REDIS_KEY, set conversion_metric per account instead")
+	}
+
+	if accountsFile != "" {
+		return accounts.NewFileRegistry(accountsFile)
+	}
+
+	if accountsRedisKey != "" {
+		return &accounts.RedisRegistry{
+			Client: redisClient,
+			Key:    accountsRedisKey,
+		}, nil
+	}
+
 	klaviyoAPIKey := os.Getenv("KLAVIYO_API_KEY")
 	if klaviyoAPIKey == "" {
-		return nil, fmt.Errorf("KLAVIYO_API_KEY not set")
+		return nil, fmt.Errorf("one of KLAVIYO_ACCOUNTS_FILE, KLAVIYO_ACCOUNTS_REDIS_KEY or KLAVIYO_API_KEY must be set")
 	}
 
-	headerValue := fmt.Sprintf("Klaviyo-API-Key %s", klaviyoAPIKey)
-	apiKeyProvider, err := securityprovider.NewSecurityProviderApiKey("header", "Authorization", headerValue)
+	klaviyoClient, err := klaviyoclient.New(klaviyoAPIKey)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("error creating klaviyo client: %w", err)
 	}
 
-	editorFn := klaviyo.WithRequestEditorFn(apiKeyProvider.Intercept)
-	klaviyoClient, err := klaviyo.NewClientWithResponses("https://a.klaviyo.com", editorFn)
+	// Private keys belong to a single account so look it up.
+	res, err := klaviyoClient.GetAccountsWithResponse(ctx, &klaviyo.GetAccountsParams{
+		Revision: "2023-12-15",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+	accountsRes, err := klaviyoclient.CheckResponse(res.StatusCode(), res.Body, res.JSON200)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+	if len(accountsRes.Data) == 0 {
+		return nil, fmt.Errorf("failed to find account for KLAVIYO_API_KEY")
+	}
 
-	return klaviyoClient, err
+	account := accountsRes.Data[0]
+	return accounts.NewStaticRegistry(accounts.Account{
+		ID:               account.Id,
+		Name:             account.Attributes.ContactInformation.OrganizationName,
+		PrivateKey:       klaviyoAPIKey,
+		ConversionMetric: os.Getenv("KLAVIYO_CONVERSION_METRIC"),
+	}), nil
 }
 
 func createRedisClient(ctx context.Context) (*redis.Client, error) {
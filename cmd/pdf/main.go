@@ -0,0 +1,73 @@
+// Command pdf renders a report from the JSON API as a PDF, e.g.
+//
+//	curl "$APP_URL/api/v1/reports/AbC123?api_key=$API_KEY" | go run ./cmd/pdf -logo logo.png -o report.pdf
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/oliverbenns/klaviyo-report/internal/server/api"
+)
+
+func main() {
+	err := run()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	in := flag.String("i", "-", "report JSON file, - for stdin")
+	out := flag.String("o", "report.pdf", "output PDF file, - for stdout")
+	logo := flag.String("logo", "", "optional PNG or JPEG logo")
+	charts := flag.Bool("charts", false, "include charts")
+	flag.Parse()
+
+	var r io.Reader = os.Stdin
+	if *in != "-" {
+		f, err := os.Open(*in)
+		if err != nil {
+			return fmt.Errorf("error opening input: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	data := api.KlaviyoReportTemplateData{}
+	err := json.NewDecoder(r).Decode(&data)
+	if err != nil {
+		return fmt.Errorf("error decoding report: %w", err)
+	}
+
+	opts := api.PDFOptions{
+		Charts: *charts,
+	}
+	if *logo != "" {
+		opts.Logo, err = os.ReadFile(*logo)
+		if err != nil {
+			return fmt.Errorf("error reading logo: %w", err)
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "-" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return fmt.Errorf("error creating output: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	err = api.RenderKlaviyoReportPDF(w, data, opts)
+	if err != nil {
+		return fmt.Errorf("error rendering pdf: %w", err)
+	}
+
+	return nil
+}
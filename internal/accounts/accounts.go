@@ -0,0 +1,70 @@
+package accounts
+
+import (
+	"context"
+	"errors"
+	"sort"
+)
+
+var ErrNotFound = errors.New("account not found")
+
+// Account is a Klaviyo account and the private API key used to access it.
+type Account struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	PrivateKey string `json:"private_key"`
+	// Logo is an optional path or URL to a PNG or JPEG used in PDF reports.
+	Logo string `json:"logo,omitempty"`
+	// ConversionMetric is an optional name or ID of the metric orders are
+	// counted from. When empty a Placed Order metric is discovered from the
+	// account's e-commerce integration.
+	ConversionMetric string `json:"conversion_metric,omitempty"`
+	// SMSSegmentCosts is the cost of sending one SMS segment by To Phone
+	// Region (e.g. "US"), with "*" for any other region. SMS campaign costs
+	// are only reported when set.
+	SMSSegmentCosts map[string]float64 `json:"sms_segment_costs,omitempty"`
+}
+
+type Registry interface {
+	List(ctx context.Context) ([]Account, error)
+	Get(ctx context.Context, id string) (Account, error)
+}
+
+// StaticRegistry is an in memory registry.
+type StaticRegistry struct {
+	accounts map[string]Account
+}
+
+func NewStaticRegistry(accounts ...Account) *StaticRegistry {
+	r := &StaticRegistry{accounts: map[string]Account{}}
+	for _, account := range accounts {
+		r.accounts[account.ID] = account
+	}
+	return r
+}
+
+func (r *StaticRegistry) List(ctx context.Context) ([]Account, error) {
+	accounts := make([]Account, 0, len(r.accounts))
+	for _, account := range r.accounts {
+		accounts = append(accounts, account)
+	}
+	sortAccounts(accounts)
+	return accounts, nil
+}
+
+func (r *StaticRegistry) Get(ctx context.Context, id string) (Account, error) {
+	account, ok := r.accounts[id]
+	if !ok {
+		return Account{}, ErrNotFound
+	}
+	return account, nil
+}
+
+func sortAccounts(accounts []Account) {
+	sort.Slice(accounts, func(i, j int) bool {
+		if accounts[i].Name != accounts[j].Name {
+			return accounts[i].Name < accounts[j].Name
+		}
+		return accounts[i].ID < accounts[j].ID
+	})
+}
@@ -0,0 +1,32 @@
+package accounts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// NewFileRegistry loads accounts from a JSON file containing a list of
+// accounts, e.g.
+//
+//	[{"id": "AbC123", "name": "Brand", "private_key": "pk_..."}]
+func NewFileRegistry(path string) (*StaticRegistry, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read accounts file: %w", err)
+	}
+
+	accounts := []Account{}
+	err = json.Unmarshal(b, &accounts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse accounts file: %w", err)
+	}
+
+	for i, account := range accounts {
+		if account.ID == "" || account.PrivateKey == "" {
+			return nil, fmt.Errorf("account %d in accounts file is missing id or private_key", i)
+		}
+	}
+
+	return NewStaticRegistry(accounts...), nil
+}
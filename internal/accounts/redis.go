@@ -0,0 +1,63 @@
+package accounts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+const DefaultRedisKey = "klaviyo-report:accounts"
+
+// RedisRegistry reads accounts from a Redis hash keyed by account ID, with
+// each value being the JSON encoded account, e.g.
+//
+//	HSET klaviyo-report:accounts AbC123 '{"name": "Brand", "private_key": "pk_..."}'
+type RedisRegistry struct {
+	Client *redis.Client
+	Key    string
+}
+
+func (r *RedisRegistry) List(ctx context.Context) ([]Account, error) {
+	values, err := r.Client.HGetAll(ctx, r.Key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+
+	accounts := make([]Account, 0, len(values))
+	for id, value := range values {
+		account, err := decodeRedisAccount(id, value)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+
+	sortAccounts(accounts)
+	return accounts, nil
+}
+
+func (r *RedisRegistry) Get(ctx context.Context, id string) (Account, error) {
+	value, err := r.Client.HGet(ctx, r.Key, id).Result()
+	if errors.Is(err, redis.Nil) {
+		return Account{}, ErrNotFound
+	}
+	if err != nil {
+		return Account{}, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	return decodeRedisAccount(id, value)
+}
+
+func decodeRedisAccount(id string, value string) (Account, error) {
+	account := Account{}
+	err := json.Unmarshal([]byte(value), &account)
+	if err != nil {
+		return Account{}, fmt.Errorf("failed to parse account %s: %w", id, err)
+	}
+
+	account.ID = id
+	return account, nil
+}
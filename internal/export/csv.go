@@ -0,0 +1,64 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+func WriteCSV(w io.Writer, table Table) error {
+	cw := csv.NewWriter(w)
+
+	err := cw.Write(table.Headers)
+	if err != nil {
+		return fmt.Errorf("failed to write csv header: %w", err)
+	}
+
+	for _, row := range table.Rows {
+		record := make([]string, len(row))
+		for i, cell := range row {
+			record[i] = formatCSVCell(cell)
+		}
+
+		err = cw.Write(record)
+		if err != nil {
+			return fmt.Errorf("failed to write csv row: %w", err)
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvFormulaPrefixes are the leading characters spreadsheets read as the
+// start of a formula.
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// formatCSVCell formats a cell, prefixing strings that would be read as a
+// formula, e.g. a campaign named "=HYPERLINK(...)", with ' so they stay text.
+func formatCSVCell(cell any) string {
+	value := formatCell(cell)
+	if _, ok := cell.(string); ok && value != "" && strings.ContainsRune(csvFormulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func formatCell(cell any) string {
+	switch v := cell.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
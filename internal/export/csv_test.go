@@ -0,0 +1,70 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteCSV(t *testing.T) {
+	buf := bytes.Buffer{}
+	err := WriteCSV(&buf, Table{
+		Headers: []string{"Name", "Recipients", "Revenue", "Winner", "Notes"},
+		Rows: [][]any{
+			{"Summer Sale", 1000, 123.45, true, nil},
+			{`Say "hi", friend`, 0, 0.1, false, "a\nb"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "Name,Recipients,Revenue,Winner,Notes\n" +
+		"Summer Sale,1000,123.45,true,\n" +
+		"\"Say \"\"hi\"\", friend\",0,0.1,false,\"a\nb\"\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestSlug(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"My Store", "my-store"},
+		{"  Café & Co. ", "caf-co"},
+		{"2024-01-01 – 2024-01-31", "2024-01-01-2024-01-31"},
+		{"---", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Slug(tt.value); got != tt.want {
+			t.Errorf("Slug(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestFormatCSVCell(t *testing.T) {
+	tests := []struct {
+		cell any
+		want string
+	}{
+		{"Summer Sale", "Summer Sale"},
+		{"=HYPERLINK(\"https://example.com\")", "'=HYPERLINK(\"https://example.com\")"},
+		{"+1 offer", "'+1 offer"},
+		{"-20% today", "'-20% today"},
+		{"@everyone", "'@everyone"},
+		{"\t=1+1", "'\t=1+1"},
+		{"Sale = 20% off", "Sale = 20% off"},
+		{"", ""},
+		{-12.5, "-12.5"},
+		{-3, "-3"},
+		{nil, ""},
+	}
+
+	for _, tt := range tests {
+		if got := formatCSVCell(tt.cell); got != tt.want {
+			t.Errorf("formatCSVCell(%q) = %q, want %q", tt.cell, got, tt.want)
+		}
+	}
+}
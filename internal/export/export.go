@@ -0,0 +1,21 @@
+package export
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Table is a simple tabular export. Cells are strings, ints or float64s and
+// are written as raw values so they can be used in spreadsheets.
+type Table struct {
+	Name    string
+	Headers []string
+	Rows    [][]any
+}
+
+var slugRe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Slug makes a value safe to use in a filename.
+func Slug(value string) string {
+	return strings.Trim(slugRe.ReplaceAllString(strings.ToLower(value), "-"), "-")
+}
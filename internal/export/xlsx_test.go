@@ -0,0 +1,134 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestColumnName(t *testing.T) {
+	tests := []struct {
+		index int
+		want  string
+	}{
+		{0, "A"},
+		{25, "Z"},
+		{26, "AA"},
+		{27, "AB"},
+		{51, "AZ"},
+		{52, "BA"},
+		{701, "ZZ"},
+		{702, "AAA"},
+	}
+
+	for _, tt := range tests {
+		if got := columnName(tt.index); got != tt.want {
+			t.Errorf("columnName(%d) = %s, want %s", tt.index, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateSheetName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Campaigns", "Campaigns"},
+		{strings.Repeat("a", 31), strings.Repeat("a", 31)},
+		{strings.Repeat("a", 40), strings.Repeat("a", 31)},
+		{strings.Repeat("é", 40), strings.Repeat("é", 31)},
+	}
+
+	for _, tt := range tests {
+		if got := truncateSheetName(tt.name); got != tt.want {
+			t.Errorf("truncateSheetName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestXLSXSheetCells(t *testing.T) {
+	tests := []struct {
+		name string
+		cell any
+		want string
+	}{
+		{"empty", nil, `<c r="A2"/>`},
+		{"int", 42, `<c r="A2"><v>42</v></c>`},
+		{"float", 0.125, `<c r="A2"><v>0.125</v></c>`},
+		{"large float", 1234567.5, `<c r="A2"><v>1234567.5</v></c>`},
+		{"true", true, `<c r="A2" t="b"><v>1</v></c>`},
+		{"false", false, `<c r="A2" t="b"><v>0</v></c>`},
+		{"string", "Summer <Sale> & more", `<c r="A2" t="inlineStr"><is><t>Summer &lt;Sale&gt; &amp; more</t></is></c>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sheet := xlsxSheet(Table{Headers: []string{"Value"}, Rows: [][]any{{tt.cell}}})
+			if !strings.Contains(sheet, `<row r="2">`+tt.want+`</row>`) {
+				t.Errorf("sheet does not contain %s:\n%s", tt.want, sheet)
+			}
+		})
+	}
+}
+
+func TestXLSXSheetHeader(t *testing.T) {
+	sheet := xlsxSheet(Table{Headers: []string{"Name", "Revenue"}})
+
+	want := `<row r="1"><c r="A1" t="inlineStr" s="1"><is><t>Name</t></is></c><c r="B1" t="inlineStr" s="1"><is><t>Revenue</t></is></c></row>`
+	if !strings.Contains(sheet, want) {
+		t.Errorf("sheet does not contain %s:\n%s", want, sheet)
+	}
+}
+
+func TestWriteXLSX(t *testing.T) {
+	buf := bytes.Buffer{}
+	err := WriteXLSX(&buf, Table{
+		Name:    "Campaigns & Flows",
+		Headers: []string{"Name", "Revenue"},
+		Rows:    [][]any{{"Summer Sale", 123.45}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{}
+	for _, file := range zr.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[file.Name] = string(content)
+	}
+
+	for _, name := range []string{
+		"[Content_Types].xml",
+		"_rels/.rels",
+		"xl/workbook.xml",
+		"xl/_rels/workbook.xml.rels",
+		"xl/styles.xml",
+		"xl/worksheets/sheet1.xml",
+	} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("missing %s", name)
+		}
+	}
+
+	if !strings.Contains(files["xl/workbook.xml"], `<sheet name="Campaigns &amp; Flows"`) {
+		t.Errorf("workbook does not name the sheet:\n%s", files["xl/workbook.xml"])
+	}
+	if !strings.Contains(files["xl/worksheets/sheet1.xml"], `<c r="B2"><v>123.45</v></c>`) {
+		t.Errorf("sheet is missing the revenue cell:\n%s", files["xl/worksheets/sheet1.xml"])
+	}
+}
@@ -0,0 +1,115 @@
+package klaviyoclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ErrorDetail is a single error from a Klaviyo JSON:API error response.
+type ErrorDetail struct {
+	ID        string `json:"id"`
+	Code      string `json:"code"`
+	Title     string `json:"title"`
+	Detail    string `json:"detail"`
+	Pointer   string `json:"pointer,omitempty"`
+	Parameter string `json:"parameter,omitempty"`
+}
+
+// APIError is a non 200 response from Klaviyo.
+type APIError struct {
+	StatusCode int
+	Errors     []ErrorDetail
+}
+
+func (e *APIError) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("klaviyo responded with status %d", e.StatusCode)
+	}
+
+	details := []string{}
+	for _, detail := range e.Errors {
+		details = append(details, detail.String())
+	}
+
+	return fmt.Sprintf("klaviyo responded with status %d: %s", e.StatusCode, strings.Join(details, "; "))
+}
+
+// Code returns the Klaviyo error code of the first error, e.g.
+// "not_authenticated".
+func (e *APIError) Code() string {
+	if len(e.Errors) == 0 {
+		return ""
+	}
+	return e.Errors[0].Code
+}
+
+func (d ErrorDetail) String() string {
+	s := fmt.Sprintf("%s: %s", d.Code, d.Detail)
+	if d.Pointer != "" {
+		s += fmt.Sprintf(" (%s)", d.Pointer)
+	} else if d.Parameter != "" {
+		s += fmt.Sprintf(" (%s)", d.Parameter)
+	}
+	return s
+}
+
+// errorBody matches the generated ClientError and ServerError types.
+type errorBody struct {
+	Errors []struct {
+		ID     string `json:"id"`
+		Code   string `json:"code"`
+		Title  string `json:"title"`
+		Detail string `json:"detail"`
+		Source *struct {
+			Parameter *string `json:"parameter,omitempty"`
+			Pointer   *string `json:"pointer,omitempty"`
+		} `json:"source,omitempty"`
+	} `json:"errors"`
+}
+
+// CheckResponse returns the parsed 200 body of a generated client response,
+// or an *APIError built from the error body for any other status, e.g.
+//
+//	res, err := client.GetAccountWithResponse(ctx, id, params)
+//	if err != nil {
+//		return nil, err
+//	}
+//	return klaviyoclient.CheckResponse(res.StatusCode(), res.Body, res.JSON200)
+func CheckResponse[T any](statusCode int, body []byte, json200 *T) (*T, error) {
+	if statusCode == 200 && json200 != nil {
+		return json200, nil
+	}
+
+	return nil, newAPIError(statusCode, body)
+}
+
+func newAPIError(statusCode int, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: statusCode}
+
+	parsed := errorBody{}
+	err := json.Unmarshal(body, &parsed)
+	if err != nil {
+		return apiErr
+	}
+
+	for _, e := range parsed.Errors {
+		detail := ErrorDetail{
+			ID:     e.ID,
+			Code:   e.Code,
+			Title:  e.Title,
+			Detail: e.Detail,
+		}
+		if e.Source != nil {
+			if e.Source.Pointer != nil {
+				detail.Pointer = *e.Source.Pointer
+			}
+			if e.Source.Parameter != nil {
+				detail.Parameter = *e.Source.Parameter
+			}
+		}
+		apiErr.Errors = append(apiErr.Errors, detail)
+	}
+
+	return apiErr
+}
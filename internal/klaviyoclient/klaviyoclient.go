@@ -0,0 +1,54 @@
+package klaviyoclient
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/deepmap/oapi-codegen/pkg/securityprovider"
+	"github.com/oliverbenns/klaviyo-report/generated/klaviyo"
+)
+
+const ServerURL = "https://a.klaviyo.com"
+
+func New(privateKey string) (*klaviyo.ClientWithResponses, error) {
+	headerValue := fmt.Sprintf("Klaviyo-API-Key %s", privateKey)
+	apiKeyProvider, err := securityprovider.NewSecurityProviderApiKey("header", "Authorization", headerValue)
+	if err != nil {
+		return nil, err
+	}
+
+	editorFn := klaviyo.WithRequestEditorFn(apiKeyProvider.Intercept)
+	httpClient := klaviyo.WithHTTPClient(&http.Client{
+		Transport: NewTransport(http.DefaultTransport),
+	})
+	return klaviyo.NewClientWithResponses(ServerURL, editorFn, httpClient)
+}
+
+// Pool lazily creates and reuses a client per private key.
+type Pool struct {
+	mu      sync.Mutex
+	clients map[string]*klaviyo.ClientWithResponses
+}
+
+func (p *Pool) Get(privateKey string) (*klaviyo.ClientWithResponses, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	client, ok := p.clients[privateKey]
+	if ok {
+		return client, nil
+	}
+
+	client, err := New(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.clients == nil {
+		p.clients = map[string]*klaviyo.ClientWithResponses{}
+	}
+	p.clients[privateKey] = client
+
+	return client, nil
+}
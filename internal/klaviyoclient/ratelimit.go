@@ -0,0 +1,140 @@
+package klaviyoclient
+
+import (
+	"context"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Klaviyo rate limits are applied per account, per endpoint, with a burst
+// (per second) and steady (per minute) window.
+// https://developers.klaviyo.com/en/docs/rate_limits_and_error_handling
+type Tier struct {
+	Burst  int
+	Steady int
+}
+
+var (
+	TierXS = Tier{Burst: 1, Steady: 15}
+	TierS  = Tier{Burst: 3, Steady: 60}
+	TierM  = Tier{Burst: 10, Steady: 150}
+	TierL  = Tier{Burst: 75, Steady: 700}
+	TierXL = Tier{Burst: 350, Steady: 3500}
+)
+
+type endpoint struct {
+	Method  string
+	Pattern *regexp.Regexp
+	Tier    Tier
+}
+
+// Endpoints not listed use TierM.
+var endpoints = []endpoint{
+	{Method: "GET", Pattern: regexp.MustCompile(`^/api/accounts/?$`), Tier: TierXS},
+	{Method: "GET", Pattern: regexp.MustCompile(`^/api/accounts/[^/]+/?$`), Tier: TierXS},
+	{Method: "GET", Pattern: regexp.MustCompile(`^/api/campaigns/?$`), Tier: TierM},
+	{Method: "GET", Pattern: regexp.MustCompile(`^/api/campaigns/[^/]+/campaign-messages/?$`), Tier: TierM},
+	{Method: "GET", Pattern: regexp.MustCompile(`^/api/campaign-recipient-estimations/[^/]+/?$`), Tier: TierM},
+	{Method: "GET", Pattern: regexp.MustCompile(`^/api/metrics/?$`), Tier: TierM},
+	{Method: "POST", Pattern: regexp.MustCompile(`^/api/metric-aggregates/?$`), Tier: TierS},
+	{Method: "GET", Pattern: regexp.MustCompile(`^/api/flows/?$`), Tier: TierM},
+	{Method: "GET", Pattern: regexp.MustCompile(`^/api/flows/[^/]+/flow-actions/?$`), Tier: TierM},
+	{Method: "GET", Pattern: regexp.MustCompile(`^/api/flow-actions/[^/]+/flow-messages/?$`), Tier: TierM},
+	{Method: "GET", Pattern: regexp.MustCompile(`^/api/lists/[^/]+/?$`), Tier: TierL},
+	{Method: "GET", Pattern: regexp.MustCompile(`^/api/segments/[^/]+/?$`), Tier: TierL},
+}
+
+// endpointFor returns the key requests are limited by and the tier.
+func endpointFor(method string, path string) (string, Tier) {
+	for _, e := range endpoints {
+		if e.Method == method && e.Pattern.MatchString(path) {
+			return e.Method + " " + e.Pattern.String(), e.Tier
+		}
+	}
+	return method + " " + path, TierM
+}
+
+// bucket is a token bucket refilled continuously at rate tokens per second.
+type bucket struct {
+	capacity float64
+	rate     float64
+	tokens   float64
+	updated  time.Time
+}
+
+func newBucket(capacity int, per time.Duration, now time.Time) *bucket {
+	return &bucket{
+		capacity: float64(capacity),
+		rate:     float64(capacity) / per.Seconds(),
+		tokens:   float64(capacity),
+		updated:  now,
+	}
+}
+
+func (b *bucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.updated).Seconds() * b.rate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.updated = now
+}
+
+// wait returns how long until a token is available.
+func (b *bucket) wait() time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// limiter enforces both the burst and steady windows of a tier.
+type limiter struct {
+	mu     sync.Mutex
+	burst  *bucket
+	steady *bucket
+}
+
+func newLimiter(tier Tier) *limiter {
+	now := time.Now()
+	return &limiter{
+		burst:  newBucket(tier.Burst, time.Second, now),
+		steady: newBucket(tier.Steady, time.Minute, now),
+	}
+}
+
+// Wait blocks until a request is allowed or the context is done.
+func (l *limiter) Wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.burst.refill(now)
+		l.steady.refill(now)
+
+		delay := max(l.burst.wait(), l.steady.wait())
+		if delay == 0 {
+			l.burst.tokens--
+			l.steady.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+		l.mu.Unlock()
+
+		err := sleep(ctx, delay)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
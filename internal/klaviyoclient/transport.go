@@ -0,0 +1,201 @@
+package klaviyoclient
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxRetries   = 5
+	defaultBaseDelay    = 1 * time.Second
+	defaultMaxDelay     = 60 * time.Second
+	defaultMaxRetryTime = 30 * time.Second
+)
+
+// RetryError is returned once a request has been retried MaxRetries times,
+// or retrying would take longer than MaxRetryTime, and Klaviyo is still
+// throttling or erroring.
+type RetryError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Attempts   int
+	RetryAfter time.Duration
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("klaviyo %s %s failed with status %d after %d attempts", e.Method, e.URL, e.StatusCode, e.Attempts)
+}
+
+// Transport rate limits requests per endpoint on the client side and retries
+// throttled (429) and server error (5xx) responses with exponential backoff.
+type Transport struct {
+	Base       http.RoundTripper
+	MaxRetries int
+	BaseDelay  time.Duration
+	// MaxDelay caps each wait between attempts, Retry-After included.
+	MaxDelay time.Duration
+	// MaxRetryTime is the total time a request may spend waiting to be
+	// retried, so a throttled account can't hold a handler open for minutes.
+	MaxRetryTime time.Duration
+
+	mu       sync.Mutex
+	limiters map[string]*limiter
+}
+
+func NewTransport(base http.RoundTripper) *Transport {
+	return &Transport{
+		Base:         base,
+		MaxRetries:   defaultMaxRetries,
+		BaseDelay:    defaultBaseDelay,
+		MaxDelay:     defaultMaxDelay,
+		MaxRetryTime: defaultMaxRetryTime,
+	}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	l := t.limiter(req)
+	retryTime := time.Duration(0)
+
+	for attempt := 0; ; attempt++ {
+		err := l.Wait(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		attemptReq, err := rewind(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := t.base().RoundTrip(attemptReq)
+		if err != nil {
+			return nil, err
+		}
+
+		if !retryable(res.StatusCode) {
+			return res, nil
+		}
+
+		retryAfter := parseRetryAfter(res.Header.Get("Retry-After"), time.Now())
+		drain(res)
+
+		delay := retryAfter
+		if delay == 0 {
+			delay = t.backoff(attempt)
+		}
+		if t.MaxDelay > 0 {
+			delay = min(delay, t.MaxDelay)
+		}
+
+		if attempt >= t.MaxRetries || (t.MaxRetryTime > 0 && retryTime+delay > t.MaxRetryTime) {
+			return nil, &RetryError{
+				Method:     req.Method,
+				URL:        req.URL.String(),
+				StatusCode: res.StatusCode,
+				Attempts:   attempt + 1,
+				RetryAfter: retryAfter,
+			}
+		}
+
+		err = sleep(ctx, delay)
+		if err != nil {
+			return nil, err
+		}
+		retryTime += delay
+	}
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base == nil {
+		return http.DefaultTransport
+	}
+	return t.Base
+}
+
+func (t *Transport) limiter(req *http.Request) *limiter {
+	key, tier := endpointFor(req.Method, req.URL.Path)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.limiters == nil {
+		t.limiters = map[string]*limiter{}
+	}
+
+	l, ok := t.limiters[key]
+	if !ok {
+		l = newLimiter(tier)
+		t.limiters[key] = l
+	}
+
+	return l
+}
+
+// backoff returns an exponential delay with full jitter.
+func (t *Transport) backoff(attempt int) time.Duration {
+	delay := t.BaseDelay << attempt
+	if delay <= 0 || delay > t.MaxDelay {
+		delay = t.MaxDelay
+	}
+	return time.Duration(rand.Int63n(int64(delay)) + 1)
+}
+
+func retryable(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+// rewind returns a request that can be sent for the attempt, resetting the
+// body for retries.
+func rewind(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 0 || req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+
+	if req.GetBody == nil {
+		return nil, fmt.Errorf("klaviyo %s %s cannot be retried as the body is not rewindable", req.Method, req.URL)
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("failed to rewind request body: %w", err)
+	}
+
+	clone := req.Clone(req.Context())
+	clone.Body = body
+	return clone, nil
+}
+
+// parseRetryAfter parses the Retry-After header which is either a number of
+// seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	seconds, err := strconv.Atoi(value)
+	if err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	date, err := http.ParseTime(value)
+	if err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+
+	return 0
+}
+
+func drain(res *http.Response) {
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+}
@@ -0,0 +1,173 @@
+package klaviyoclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 15, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{"empty", "", 0},
+		{"seconds", "5", 5 * time.Second},
+		{"zero seconds", "0", 0},
+		{"negative seconds", "-1", 0},
+		{"future date", now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second},
+		{"past date", now.Add(-30 * time.Second).Format(http.TimeFormat), 0},
+		{"invalid", "soon", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseRetryAfter(tt.value, now)
+			if got != tt.want {
+				t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func response(statusCode int, retryAfter string) *http.Response {
+	res := &http.Response{
+		StatusCode: statusCode,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader("")),
+	}
+	if retryAfter != "" {
+		res.Header.Set("Retry-After", retryAfter)
+	}
+	return res
+}
+
+func TestTransportRoundTrip(t *testing.T) {
+	tests := []struct {
+		name         string
+		responses    []*http.Response
+		maxRetryTime time.Duration
+		wantStatus   int
+		wantAttempts int
+		wantRetryErr bool
+	}{
+		{
+			name:         "success",
+			responses:    []*http.Response{response(200, "")},
+			wantStatus:   200,
+			wantAttempts: 1,
+		},
+		{
+			name:         "client errors are not retried",
+			responses:    []*http.Response{response(404, "")},
+			wantStatus:   404,
+			wantAttempts: 1,
+		},
+		{
+			name:         "retries throttled and server errors",
+			responses:    []*http.Response{response(429, ""), response(503, ""), response(200, "")},
+			wantStatus:   200,
+			wantAttempts: 3,
+		},
+		{
+			name:         "retry after is capped at max delay",
+			responses:    []*http.Response{response(429, "600"), response(200, "")},
+			wantStatus:   200,
+			wantAttempts: 2,
+		},
+		{
+			name:         "gives up after max retries",
+			responses:    []*http.Response{response(500, ""), response(500, ""), response(500, "")},
+			wantAttempts: 3,
+			wantRetryErr: true,
+		},
+		{
+			name:         "gives up once the retry time budget is spent",
+			responses:    []*http.Response{response(429, "1"), response(200, "")},
+			maxRetryTime: time.Microsecond,
+			wantAttempts: 1,
+			wantRetryErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			transport := NewTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				res := tt.responses[attempts]
+				attempts++
+				return res, nil
+			}))
+			transport.MaxRetries = 2
+			transport.BaseDelay = time.Millisecond
+			transport.MaxDelay = time.Millisecond
+			if tt.maxRetryTime > 0 {
+				transport.MaxRetryTime = tt.maxRetryTime
+			}
+
+			req, err := http.NewRequest("GET", "https://a.klaviyo.com/api/lists/AbC123/", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res, err := transport.RoundTrip(req)
+			if attempts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+
+			if tt.wantRetryErr {
+				var retryErr *RetryError
+				if !errors.As(err, &retryErr) {
+					t.Fatalf("err = %v, want RetryError", err)
+				}
+				if retryErr.Attempts != tt.wantAttempts {
+					t.Errorf("RetryError.Attempts = %d, want %d", retryErr.Attempts, tt.wantAttempts)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", res.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestTransportRoundTripContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	transport := NewTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		cancel()
+		return response(429, "30"), nil
+	}))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://a.klaviyo.com/api/lists/AbC123/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	_, err = transport.RoundTrip(req)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("waited %s after the context was canceled", time.Since(start))
+	}
+}
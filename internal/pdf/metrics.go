@@ -0,0 +1,33 @@
+package pdf
+
+// Helvetica glyph widths (per 1000 units of font size) for ASCII 32-126,
+// from the Adobe font metrics.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// Helvetica-Bold is close enough to Helvetica scaled for layout purposes.
+const boldScale = 1.06
+
+// TextWidth returns the width of the text in points.
+func TextWidth(text string, size float64, bold bool) float64 {
+	units := 0
+	for _, r := range text {
+		if r >= 32 && r <= 126 {
+			units += helveticaWidths[r-32]
+		} else {
+			units += 556
+		}
+	}
+
+	width := float64(units) * size / 1000
+	if bold {
+		width *= boldScale
+	}
+	return width
+}
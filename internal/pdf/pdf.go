@@ -0,0 +1,221 @@
+// Package pdf is a minimal PDF writer supporting text in the standard
+// Helvetica fonts, lines, filled rectangles and images. It exists so reports
+// can be rendered without a headless browser or cgo.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"io"
+	"strings"
+
+	// Register decoders for LoadImage.
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// A4 portrait in points.
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+type Color struct {
+	R, G, B float64
+}
+
+var (
+	Black     = Color{0, 0, 0}
+	Grey      = Color{0.45, 0.45, 0.45}
+	LightGrey = Color{0.93, 0.93, 0.93}
+)
+
+type Document struct {
+	pages  []*Page
+	images []*Image
+}
+
+type Page struct {
+	content bytes.Buffer
+}
+
+type Image struct {
+	Width  int
+	Height int
+	data   []byte
+	id     int
+}
+
+func New() *Document {
+	return &Document{}
+}
+
+func (d *Document) AddPage() *Page {
+	page := &Page{}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// LoadImage decodes a PNG or JPEG image for use on any page. Transparency
+// is flattened onto white.
+func (d *Document) LoadImage(r io.Reader) (*Image, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	bounds := img.Bounds()
+	raw := make([]byte, 0, bounds.Dx()*bounds.Dy()*3)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			// Colours are alpha premultiplied so add the remaining white.
+			white := 0xffff - a
+			raw = append(raw, byte((r+white)>>8), byte((g+white)>>8), byte((b+white)>>8))
+		}
+	}
+
+	compressed := bytes.Buffer{}
+	zw := zlib.NewWriter(&compressed)
+	_, err = zw.Write(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compress image: %w", err)
+	}
+	err = zw.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to compress image: %w", err)
+	}
+
+	pdfImage := &Image{
+		Width:  bounds.Dx(),
+		Height: bounds.Dy(),
+		data:   compressed.Bytes(),
+		id:     len(d.images) + 1,
+	}
+	d.images = append(d.images, pdfImage)
+
+	return pdfImage, nil
+}
+
+// Coordinates are from the top left of the page, y increasing downwards,
+// and converted to PDF's bottom left origin when written.
+
+// Text draws text with its baseline at y.
+func (p *Page) Text(x, y, size float64, bold bool, color Color, text string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(&p.content, "BT %.3f %.3f %.3f rg /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n",
+		color.R, color.G, color.B, font, size, x, PageHeight-y, escape(text))
+}
+
+// TextRight draws text right aligned to x.
+func (p *Page) TextRight(x, y, size float64, bold bool, color Color, text string) {
+	p.Text(x-TextWidth(text, size, bold), y, size, bold, color, text)
+}
+
+func (p *Page) Line(x1, y1, x2, y2, width float64, color Color) {
+	fmt.Fprintf(&p.content, "%.3f %.3f %.3f RG %.2f w %.2f %.2f m %.2f %.2f l S\n",
+		color.R, color.G, color.B, width, x1, PageHeight-y1, x2, PageHeight-y2)
+}
+
+func (p *Page) Rect(x, y, w, h float64, color Color) {
+	fmt.Fprintf(&p.content, "%.3f %.3f %.3f rg %.2f %.2f %.2f %.2f re f\n",
+		color.R, color.G, color.B, x, PageHeight-y-h, w, h)
+}
+
+func (p *Page) Image(img *Image, x, y, w, h float64) {
+	fmt.Fprintf(&p.content, "q %.2f 0 0 %.2f %.2f %.2f cm /Im%d Do Q\n", w, h, x, PageHeight-y-h, img.id)
+}
+
+// WriteTo writes the document.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	buf := bytes.Buffer{}
+	offsets := []int{}
+
+	// Object numbers: 1 catalog, 2 pages, 3 & 4 fonts, then images, then a
+	// page and content stream per page.
+	imageObj := func(i int) int { return 5 + i }
+	pageObj := func(i int) int { return 5 + len(d.images) + i*2 }
+
+	startObj := func() {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n", len(offsets))
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	startObj()
+	buf.WriteString("<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+
+	kids := []string{}
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageObj(i)))
+	}
+	startObj()
+	fmt.Fprintf(&buf, "<< /Type /Pages /Kids [%s] /Count %d >>\nendobj\n", strings.Join(kids, " "), len(d.pages))
+
+	startObj()
+	buf.WriteString("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>\nendobj\n")
+	startObj()
+	buf.WriteString("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>\nendobj\n")
+
+	xObjects := []string{}
+	for i, img := range d.images {
+		startObj()
+		fmt.Fprintf(&buf, "<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode /Length %d >>\nstream\n",
+			img.Width, img.Height, len(img.data))
+		buf.Write(img.data)
+		buf.WriteString("\nendstream\nendobj\n")
+		xObjects = append(xObjects, fmt.Sprintf("/Im%d %d 0 R", img.id, imageObj(i)))
+	}
+
+	for i, page := range d.pages {
+		startObj()
+		fmt.Fprintf(&buf, "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> /XObject << %s >> >> /Contents %d 0 R >>\nendobj\n",
+			PageWidth, PageHeight, strings.Join(xObjects, " "), pageObj(i)+1)
+
+		startObj()
+		fmt.Fprintf(&buf, "<< /Length %d >>\nstream\n", page.content.Len())
+		buf.Write(page.content.Bytes())
+		buf.WriteString("\nendstream\nendobj\n")
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buf.WriteTo(w)
+}
+
+// escape encodes text as a WinAnsi PDF string. Characters outside of
+// Latin-1 (other than €) are replaced.
+func escape(text string) string {
+	sb := strings.Builder{}
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case r == '€':
+			sb.WriteString("\\200")
+		case r == '–':
+			sb.WriteString("\\226")
+		case r < 32:
+			sb.WriteByte(' ')
+		case r < 128:
+			sb.WriteRune(r)
+		case r < 256:
+			fmt.Fprintf(&sb, "\\%03o", r)
+		default:
+			sb.WriteByte('?')
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oliverbenns/klaviyo-report/generated/klaviyo"
+	"github.com/oliverbenns/klaviyo-report/internal/accounts"
+)
+
+type klaviyoClientKey struct{}
+
+type accountKey struct{}
+
+// withKlaviyoAccount returns a request context carrying the account, its
+// Klaviyo client and cache options.
+func (s *Service) withKlaviyoAccount(c *gin.Context, account accounts.Account) (context.Context, error) {
+	client, err := s.KlaviyoClients.Get(account.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create klaviyo client: %w", err)
+	}
+
+	ctx := withCacheOptions(c, account.ID)
+	ctx = context.WithValue(ctx, accountKey{}, account)
+	return context.WithValue(ctx, klaviyoClientKey{}, client), nil
+}
+
+// account returns the account the request is for.
+func (s *Service) account(ctx context.Context) accounts.Account {
+	account, ok := ctx.Value(accountKey{}).(accounts.Account)
+	if !ok {
+		panic("account not set on context")
+	}
+	return account
+}
+
+// klaviyo returns the Klaviyo client for the account the request is for.
+func (s *Service) klaviyo(ctx context.Context) *klaviyo.ClientWithResponses {
+	client, ok := ctx.Value(klaviyoClientKey{}).(*klaviyo.ClientWithResponses)
+	if !ok {
+		panic("klaviyo client not set on context")
+	}
+	return client
+}
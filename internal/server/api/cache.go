@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	redis "github.com/redis/go-redis/v9"
+)
+
+// How long each Klaviyo resource is cached for. Reporting data changes often
+// so is kept short, account configuration rarely changes.
+var cacheTTLs = map[string]time.Duration{
+	"account":              6 * time.Hour,
+	"accounts":             6 * time.Hour,
+	"metrics":              6 * time.Hour,
+	"campaigns":            15 * time.Minute,
+	"campaign-messages":    1 * time.Hour,
+	"recipient-estimation": 1 * time.Hour,
+	"metric-aggregates":    15 * time.Minute,
+	"flows":                1 * time.Hour,
+	"flow-actions":         1 * time.Hour,
+	"flow-action-messages": 1 * time.Hour,
+	"lists":                6 * time.Hour,
+	"segments":             6 * time.Hour,
+}
+
+const defaultCacheTTL = 15 * time.Minute
+
+type cacheOptionsKey struct{}
+
+type cacheOptions struct {
+	AccountID string
+	Refresh   bool
+}
+
+// withCacheOptions scopes cache keys to the account and allows ?refresh=1
+// to bypass cached responses for the request.
+func withCacheOptions(c *gin.Context, accountID string) context.Context {
+	return context.WithValue(c.Request.Context(), cacheOptionsKey{}, cacheOptions{
+		AccountID: accountID,
+		Refresh:   c.Query("refresh") == "1",
+	})
+}
+
+func getCacheOptions(ctx context.Context) cacheOptions {
+	opts, _ := ctx.Value(cacheOptionsKey{}).(cacheOptions)
+	return opts
+}
+
+func cacheKey(accountID string, resource string, params ...any) (string, error) {
+	b, err := json.Marshal(params)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal cache key params: %w", err)
+	}
+
+	if accountID == "" {
+		accountID = "default"
+	}
+
+	return fmt.Sprintf("klaviyo:%s:%s:%x", accountID, resource, sha256.Sum256(b)), nil
+}
+
+// cacheResponse returns the cached Klaviyo response for the resource and
+// params, calling fn and caching the result on a miss. Nil results (non 200
+// responses) are never cached.
+func cacheResponse[T any](ctx context.Context, s *Service, resource string, params any, fn func() (*T, error)) (*T, error) {
+	if s.RedisClient == nil {
+		return fn()
+	}
+
+	opts := getCacheOptions(ctx)
+	key, err := cacheKey(opts.AccountID, resource, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if !opts.Refresh {
+		b, err := s.RedisClient.Get(ctx, key).Bytes()
+		switch {
+		case err == nil:
+			value := new(T)
+			err = json.Unmarshal(b, value)
+			if err == nil {
+				s.Logger.Info("cache hit", "key", key, "resource", resource)
+				return value, nil
+			}
+			s.Logger.Warn("failed to unmarshal cached response", "key", key, "error", err)
+		case errors.Is(err, redis.Nil):
+		default:
+			s.Logger.Warn("failed to read from cache", "key", key, "error", err)
+		}
+	}
+
+	s.Logger.Info("cache miss", "key", key, "resource", resource, "refresh", opts.Refresh)
+
+	value, err := fn()
+	if err != nil || value == nil {
+		return value, err
+	}
+
+	ttl, ok := cacheTTLs[resource]
+	if !ok {
+		ttl = defaultCacheTTL
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		s.Logger.Warn("failed to marshal response for cache", "key", key, "error", err)
+		return value, nil
+	}
+
+	err = s.RedisClient.Set(ctx, key, b, ttl).Err()
+	if err != nil {
+		s.Logger.Warn("failed to write to cache", "key", key, "error", err)
+	}
+
+	return value, nil
+}
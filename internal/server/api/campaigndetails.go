@@ -0,0 +1,221 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/oliverbenns/klaviyo-report/generated/klaviyo"
+	"github.com/oliverbenns/klaviyo-report/internal/conv"
+	"github.com/oliverbenns/klaviyo-report/internal/klaviyoclient"
+)
+
+// CampaignAudience is a list or segment a campaign was sent to.
+type CampaignAudience struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Type is "list" or "segment", empty when the audience no longer exists.
+	Type string `json:"type"`
+}
+
+type CampaignAudiences struct {
+	Included []CampaignAudience `json:"included"`
+	Excluded []CampaignAudience `json:"excluded"`
+}
+
+// campaignContent is the subject line and preview text of a campaign's email
+// message.
+type campaignContent struct {
+	Subject     string
+	PreviewText string
+}
+
+// getCampaignContents fetches the email content of each email campaign
+// concurrently, keyed by campaign ID.
+func (s *Service) getCampaignContents(ctx context.Context, campaigns []reportCampaign) (map[string]campaignContent, error) {
+	emailCampaigns := []reportCampaign{}
+	for _, campaign := range campaigns {
+		if campaign.Channel == ChannelEmail {
+			emailCampaigns = append(emailCampaigns, campaign)
+		}
+	}
+
+	contents := make([]campaignContent, len(emailCampaigns))
+	err := forEach(ctx, len(emailCampaigns), s.klaviyoConcurrency(), func(ctx context.Context, i int) error {
+		res, err := s.getCampaignMessages(ctx, emailCampaigns[i].ID)
+		if err != nil {
+			return err
+		}
+
+		for _, message := range res.Data {
+			if message.Attributes.Channel != ChannelEmail {
+				continue
+			}
+
+			content, err := message.Attributes.Content.AsEmailContentSubObject()
+			if err != nil {
+				return fmt.Errorf("failed to parse email content: %w", err)
+			}
+			contents[i] = campaignContent{
+				Subject:     conv.Val(content.Subject),
+				PreviewText: conv.Val(content.PreviewText),
+			}
+			break
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byID := map[string]campaignContent{}
+	for i, campaign := range emailCampaigns {
+		byID[campaign.ID] = contents[i]
+	}
+
+	return byID, nil
+}
+
+// getCampaignAudiences resolves the included and excluded audiences of each
+// campaign to their list or segment names, keyed by campaign ID.
+func (s *Service) getCampaignAudiences(ctx context.Context, campaigns []reportCampaign) (map[string]CampaignAudiences, error) {
+	ids := []string{}
+	seen := map[string]bool{}
+	for _, campaign := range campaigns {
+		for _, audienceIDs := range [][]string{campaign.IncludedAudiences, campaign.ExcludedAudiences} {
+			for _, id := range audienceIDs {
+				if !seen[id] {
+					seen[id] = true
+					ids = append(ids, id)
+				}
+			}
+		}
+	}
+
+	audiences := make([]CampaignAudience, len(ids))
+	err := forEach(ctx, len(ids), s.klaviyoConcurrency(), func(ctx context.Context, i int) error {
+		audience, err := s.getAudience(ctx, ids[i])
+		if err != nil {
+			return err
+		}
+		audiences[i] = audience
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	audienceByID := map[string]CampaignAudience{}
+	for _, audience := range audiences {
+		audienceByID[audience.ID] = audience
+	}
+
+	byID := map[string]CampaignAudiences{}
+	for _, campaign := range campaigns {
+		campaignAudiences := CampaignAudiences{
+			Included: []CampaignAudience{},
+			Excluded: []CampaignAudience{},
+		}
+		for _, id := range campaign.IncludedAudiences {
+			campaignAudiences.Included = append(campaignAudiences.Included, audienceByID[id])
+		}
+		for _, id := range campaign.ExcludedAudiences {
+			campaignAudiences.Excluded = append(campaignAudiences.Excluded, audienceByID[id])
+		}
+		byID[campaign.ID] = campaignAudiences
+	}
+
+	return byID, nil
+}
+
+// getAudience looks up an audience ID as a list, then as a segment, as
+// campaigns don't say which it is. Deleted audiences are reported by ID.
+func (s *Service) getAudience(ctx context.Context, id string) (CampaignAudience, error) {
+	name, err := s.getListName(ctx, id)
+	if err == nil {
+		return CampaignAudience{ID: id, Name: name, Type: "list"}, nil
+	}
+	if !isNotFound(err) {
+		return CampaignAudience{}, err
+	}
+
+	name, err = s.getSegmentName(ctx, id)
+	if err == nil {
+		return CampaignAudience{ID: id, Name: name, Type: "segment"}, nil
+	}
+	if !isNotFound(err) {
+		return CampaignAudience{}, err
+	}
+
+	s.Logger.Warn("campaign audience not found", "audience_id", id)
+	return CampaignAudience{ID: id, Name: id}, nil
+}
+
+// audienceName is decoded from the raw list and segment responses as the
+// generated types are missing the name attribute.
+type audienceName struct {
+	Data struct {
+		Attributes struct {
+			Name string `json:"name"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+func (s *Service) getListName(ctx context.Context, id string) (string, error) {
+	params := &klaviyo.GetListParams{
+		Revision: "2023-12-15",
+	}
+	res, err := cacheResponse(ctx, s, "lists", []any{id, params}, func() (*audienceName, error) {
+		res, err := s.klaviyo(ctx).GetListWithResponse(ctx, id, params)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := klaviyoclient.CheckResponse(res.StatusCode(), res.Body, res.JSON200); err != nil {
+			return nil, err
+		}
+		return decodeAudienceName(res.Body)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get list: %w", err)
+	}
+
+	return res.Data.Attributes.Name, nil
+}
+
+func (s *Service) getSegmentName(ctx context.Context, id string) (string, error) {
+	params := &klaviyo.GetSegmentParams{
+		Revision: "2023-12-15",
+	}
+	res, err := cacheResponse(ctx, s, "segments", []any{id, params}, func() (*audienceName, error) {
+		res, err := s.klaviyo(ctx).GetSegmentWithResponse(ctx, id, params)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := klaviyoclient.CheckResponse(res.StatusCode(), res.Body, res.JSON200); err != nil {
+			return nil, err
+		}
+		return decodeAudienceName(res.Body)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get segment: %w", err)
+	}
+
+	return res.Data.Attributes.Name, nil
+}
+
+func decodeAudienceName(body []byte) (*audienceName, error) {
+	name := &audienceName{}
+	err := json.Unmarshal(body, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode audience: %w", err)
+	}
+	return name, nil
+}
+
+func isNotFound(err error) bool {
+	var apiErr *klaviyoclient.APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
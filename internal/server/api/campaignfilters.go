@@ -0,0 +1,193 @@
+package api
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oliverbenns/klaviyo-report/generated/klaviyo"
+)
+
+// CampaignFilters narrow down and order the campaign table.
+type CampaignFilters struct {
+	// Sort is a campaignSortFields key, prefixed with - for descending.
+	Sort string `json:"sort"`
+	// Search matches campaign names and tags, case insensitively.
+	Search        string `json:"search"`
+	MinRecipients int    `json:"min_recipients"`
+}
+
+// campaignSortFields are the campaign columns that can be sorted by, keyed by
+// their JSON name.
+var campaignSortFields = map[string]func(c KlaviyoReportTemplateCampaign) float64{
+	"send_time":             func(c KlaviyoReportTemplateCampaign) float64 { return float64(c.SendTime.Unix()) },
+	"total_recipients":      func(c KlaviyoReportTemplateCampaign) float64 { return float64(c.TotalRecipients) },
+	"orders_placed":         func(c KlaviyoReportTemplateCampaign) float64 { return float64(c.OrdersPlaced) },
+	"revenue":               func(c KlaviyoReportTemplateCampaign) float64 { return c.Revenue },
+	"conversion_rate":       func(c KlaviyoReportTemplateCampaign) float64 { return c.ConversionRate },
+	"conversion_value":      func(c KlaviyoReportTemplateCampaign) float64 { return c.ConversionValue },
+	"revenue_per_recipient": func(c KlaviyoReportTemplateCampaign) float64 { return c.RevenuePerRecipient },
+	"delivered":             func(c KlaviyoReportTemplateCampaign) float64 { return float64(c.Delivered) },
+	"open_rate":             func(c KlaviyoReportTemplateCampaign) float64 { return c.OpenRate },
+	"click_rate":            func(c KlaviyoReportTemplateCampaign) float64 { return c.ClickRate },
+	"click_to_open_rate":    func(c KlaviyoReportTemplateCampaign) float64 { return c.ClickToOpenRate },
+	"bounce_rate":           func(c KlaviyoReportTemplateCampaign) float64 { return c.BounceRate },
+	"unsubscribe_rate":      func(c KlaviyoReportTemplateCampaign) float64 { return c.UnsubscribeRate },
+	"spam_rate":             func(c KlaviyoReportTemplateCampaign) float64 { return c.SpamRate },
+}
+
+// parseCampaignFilters reads the campaign table filters from the query
+// string: sort (e.g. -revenue_per_recipient), search and min_recipients.
+func parseCampaignFilters(c *gin.Context) (CampaignFilters, error) {
+	filters := CampaignFilters{
+		Sort:   c.Query("sort"),
+		Search: strings.TrimSpace(c.Query("search")),
+	}
+
+	key := strings.TrimPrefix(filters.Sort, "-")
+	if _, ok := campaignSortFields[key]; key != "" && key != "name" && !ok {
+		return CampaignFilters{}, fmt.Errorf("unknown sort %q", filters.Sort)
+	}
+
+	minRecipients := c.Query("min_recipients")
+	if minRecipients != "" {
+		n, err := strconv.Atoi(minRecipients)
+		if err != nil || n < 0 {
+			return CampaignFilters{}, fmt.Errorf("invalid min_recipients %q", minRecipients)
+		}
+		filters.MinRecipients = n
+	}
+
+	return filters, nil
+}
+
+// Apply returns the campaigns matching the filters in sort order. Without a
+// sort campaigns keep Klaviyo's order.
+func (f CampaignFilters) Apply(campaigns []KlaviyoReportTemplateCampaign) []KlaviyoReportTemplateCampaign {
+	search := strings.ToLower(f.Search)
+
+	filtered := []KlaviyoReportTemplateCampaign{}
+	for _, campaign := range campaigns {
+		if campaign.TotalRecipients < f.MinRecipients {
+			continue
+		}
+		if search != "" && !campaignMatches(campaign, search) {
+			continue
+		}
+		filtered = append(filtered, campaign)
+	}
+
+	key := strings.TrimPrefix(f.Sort, "-")
+	descending := strings.HasPrefix(f.Sort, "-")
+	switch {
+	case key == "name":
+		sort.SliceStable(filtered, func(i, j int) bool {
+			a, b := strings.ToLower(filtered[i].Name), strings.ToLower(filtered[j].Name)
+			if descending {
+				return a > b
+			}
+			return a < b
+		})
+	case campaignSortFields[key] != nil:
+		value := campaignSortFields[key]
+		sort.SliceStable(filtered, func(i, j int) bool {
+			if descending {
+				return value(filtered[i]) > value(filtered[j])
+			}
+			return value(filtered[i]) < value(filtered[j])
+		})
+	}
+
+	return filtered
+}
+
+func campaignMatches(campaign KlaviyoReportTemplateCampaign, search string) bool {
+	if strings.Contains(strings.ToLower(campaign.Name), search) {
+		return true
+	}
+
+	for _, tag := range campaign.Tags {
+		if strings.Contains(strings.ToLower(tag), search) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// campaignSortURL returns the link for a column header, keeping the other
+// query parameters. Clicking the sorted column reverses the order, other
+// columns sort descending first (names A-Z).
+func campaignSortURL(query url.Values, current string, key string) string {
+	sortBy, reversed := "-"+key, key
+	if key == "name" {
+		sortBy, reversed = reversed, sortBy
+	}
+	if current == sortBy {
+		sortBy = reversed
+	}
+
+	query.Set("sort", sortBy)
+	return "?" + query.Encode()
+}
+
+func campaignSortIndicator(current string, key string) string {
+	switch current {
+	case key:
+		return "▲"
+	case "-" + key:
+		return "▼"
+	default:
+		return ""
+	}
+}
+
+// hiddenQueryInputs returns hidden inputs for the query parameters so a form
+// can change some parameters while keeping the rest.
+func hiddenQueryInputs(query url.Values, exclude ...string) template.HTML {
+	for _, key := range exclude {
+		query.Del(key)
+	}
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	sb := strings.Builder{}
+	for _, key := range keys {
+		for _, value := range query[key] {
+			fmt.Fprintf(&sb, `<input type="hidden" name="%s" value="%s" />`, html.EscapeString(key), html.EscapeString(value))
+		}
+	}
+
+	return template.HTML(sb.String())
+}
+
+// includedTagNames returns the names of the tags included with a page of
+// campaigns, keyed by tag ID.
+func includedTagNames(page *klaviyo.GetCampaignResponseCollectionCompoundDocument) (map[string]string, error) {
+	names := map[string]string{}
+	if page.Included == nil {
+		return names, nil
+	}
+
+	for _, item := range *page.Included {
+		tag, err := item.AsTagResponseObjectResource()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse included campaign resource: %w", err)
+		}
+		if tag.Type != "tag" {
+			continue
+		}
+		names[tag.Id] = tag.Attributes.Name
+	}
+
+	return names, nil
+}
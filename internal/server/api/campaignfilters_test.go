@@ -0,0 +1,148 @@
+package api
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseCampaignFilters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		query   string
+		want    CampaignFilters
+		wantErr bool
+	}{
+		{name: "empty", query: ""},
+		{name: "ascending", query: "sort=revenue", want: CampaignFilters{Sort: "revenue"}},
+		{name: "descending", query: "sort=-revenue_per_recipient", want: CampaignFilters{Sort: "-revenue_per_recipient"}},
+		{name: "send time", query: "sort=-send_time", want: CampaignFilters{Sort: "-send_time"}},
+		{name: "name", query: "sort=-name", want: CampaignFilters{Sort: "-name"}},
+		{name: "search is trimmed", query: "search=+sale+", want: CampaignFilters{Search: "sale"}},
+		{name: "min recipients", query: "min_recipients=100", want: CampaignFilters{MinRecipients: 100}},
+		{name: "unknown sort", query: "sort=subject", wantErr: true},
+		{name: "negative min recipients", query: "min_recipients=-1", wantErr: true},
+		{name: "invalid min recipients", query: "min_recipients=lots", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/?"+tt.query, nil)
+
+			got, err := parseCampaignFilters(c)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCampaignFiltersApply(t *testing.T) {
+	campaigns := []KlaviyoReportTemplateCampaign{
+		{Name: "Summer Sale", TotalRecipients: 500, Revenue: 20},
+		{Name: "newsletter", Tags: []string{"Weekly"}, TotalRecipients: 50, Revenue: 30},
+		{Name: "Black Friday", Tags: []string{"Sale"}, TotalRecipients: 1000, Revenue: 10},
+	}
+
+	tests := []struct {
+		name    string
+		filters CampaignFilters
+		want    string
+	}{
+		{name: "no filters keeps order", filters: CampaignFilters{}, want: "Summer Sale,newsletter,Black Friday"},
+		{name: "sort ascending", filters: CampaignFilters{Sort: "revenue"}, want: "Black Friday,Summer Sale,newsletter"},
+		{name: "sort descending", filters: CampaignFilters{Sort: "-total_recipients"}, want: "Black Friday,Summer Sale,newsletter"},
+		{name: "sort by name ignores case", filters: CampaignFilters{Sort: "name"}, want: "Black Friday,newsletter,Summer Sale"},
+		{name: "sort by name descending", filters: CampaignFilters{Sort: "-name"}, want: "Summer Sale,newsletter,Black Friday"},
+		{name: "search names and tags", filters: CampaignFilters{Search: "SALE"}, want: "Summer Sale,Black Friday"},
+		{name: "search tags", filters: CampaignFilters{Search: "weekly"}, want: "newsletter"},
+		{name: "min recipients", filters: CampaignFilters{MinRecipients: 500}, want: "Summer Sale,Black Friday"},
+		{name: "no matches", filters: CampaignFilters{Search: "winter"}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names := []string{}
+			for _, campaign := range tt.filters.Apply(campaigns) {
+				names = append(names, campaign.Name)
+			}
+			if got := strings.Join(names, ","); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCampaignSortURL(t *testing.T) {
+	tests := []struct {
+		current string
+		key     string
+		want    string
+	}{
+		{current: "", key: "revenue", want: "-revenue"},
+		{current: "-revenue", key: "revenue", want: "revenue"},
+		{current: "revenue", key: "revenue", want: "-revenue"},
+		{current: "-revenue", key: "open_rate", want: "-open_rate"},
+		{current: "", key: "name", want: "name"},
+		{current: "name", key: "name", want: "-name"},
+		{current: "-name", key: "name", want: "name"},
+	}
+
+	for _, tt := range tests {
+		query := url.Values{"range": {"last_month"}, "sort": {tt.current}}
+		want := "?" + url.Values{"range": {"last_month"}, "sort": {tt.want}}.Encode()
+		if got := campaignSortURL(query, tt.current, tt.key); got != want {
+			t.Errorf("campaignSortURL(%q, %q) = %s, want %s", tt.current, tt.key, got, want)
+		}
+	}
+}
+
+func TestCampaignSortIndicator(t *testing.T) {
+	tests := []struct {
+		current string
+		key     string
+		want    string
+	}{
+		{current: "revenue", key: "revenue", want: "▲"},
+		{current: "-revenue", key: "revenue", want: "▼"},
+		{current: "-revenue", key: "open_rate", want: ""},
+		{current: "", key: "revenue", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := campaignSortIndicator(tt.current, tt.key); got != tt.want {
+			t.Errorf("campaignSortIndicator(%q, %q) = %q, want %q", tt.current, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestHiddenQueryInputs(t *testing.T) {
+	query := url.Values{
+		"search": {"sale"},
+		"range":  {"last_month"},
+		"tag":    {"a", `"b"`},
+	}
+
+	got := hiddenQueryInputs(query, "search")
+	want := `<input type="hidden" name="range" value="last_month" />` +
+		`<input type="hidden" name="tag" value="a" />` +
+		`<input type="hidden" name="tag" value="&#34;b&#34;" />`
+	if string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
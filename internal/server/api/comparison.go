@@ -0,0 +1,56 @@
+package api
+
+import "math"
+
+type ComparisonRow struct {
+	Name          string  `json:"name"`
+	Current       float64 `json:"current"`
+	Previous      float64 `json:"previous"`
+	Delta         float64 `json:"delta"`
+	PercentChange float64 `json:"percent_change"`
+	// HasPercentChange is false when the previous value is zero.
+	HasPercentChange bool `json:"has_percent_change"`
+	IsCurrency       bool `json:"is_currency"`
+}
+
+type ReportComparison struct {
+	Previous ReportRange     `json:"previous_range"`
+	Rows     []ComparisonRow `json:"rows"`
+}
+
+// calculateComparison compares the attributed totals of two periods, the
+// same figures as the KPI header.
+func calculateComparison(previousRange ReportRange, current Metric, previous Metric) ReportComparison {
+	return ReportComparison{
+		Previous: previousRange,
+		Rows: []ComparisonRow{
+			newComparisonRow("Orders Placed", float64(current.Count), float64(previous.Count), false),
+			newComparisonRow("Attributed Revenue", current.Revenue, previous.Revenue, true),
+			newComparisonRow("Conversion Value", averageOrderValue(current), averageOrderValue(previous), true),
+		},
+	}
+}
+
+func newComparisonRow(name string, current float64, previous float64, isCurrency bool) ComparisonRow {
+	row := ComparisonRow{
+		Name:       name,
+		Current:    current,
+		Previous:   previous,
+		Delta:      current - previous,
+		IsCurrency: isCurrency,
+	}
+
+	if previous != 0 {
+		row.PercentChange = row.Delta / math.Abs(previous)
+		row.HasPercentChange = true
+	}
+
+	return row
+}
+
+func averageOrderValue(metric Metric) float64 {
+	if metric.Count == 0 {
+		return 0
+	}
+	return metric.Revenue / float64(metric.Count)
+}
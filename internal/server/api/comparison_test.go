@@ -0,0 +1,32 @@
+package api
+
+import "testing"
+
+func TestCalculateComparison(t *testing.T) {
+	current := MetricsByCampaignID{"c1": {Count: 6, Revenue: 300}, "f1": {Count: 4, Revenue: 200}, "": {Count: 50, Revenue: 2500}}
+	previous := MetricsByCampaignID{"c1": {Count: 8, Revenue: 400}, "": {Count: 40, Revenue: 2000}}
+
+	comparison := calculateComparison(ReportRange{}, current.AttributedTotal(), previous.AttributedTotal())
+
+	tests := []struct {
+		name          string
+		current       float64
+		previous      float64
+		percentChange float64
+	}{
+		{"Orders Placed", 10, 8, 0.25},
+		{"Attributed Revenue", 500, 400, 0.25},
+		{"Conversion Value", 50, 50, 0},
+	}
+	for i, tt := range tests {
+		row := comparison.Rows[i]
+		if row.Name != tt.name || row.Current != tt.current || row.Previous != tt.previous || !floatEqual(row.PercentChange, tt.percentChange) {
+			t.Errorf("row %d = %+v, want %s %v %v %v", i, row, tt.name, tt.current, tt.previous, tt.percentChange)
+		}
+	}
+
+	empty := calculateComparison(ReportRange{}, current.AttributedTotal(), Metric{})
+	if empty.Rows[0].HasPercentChange {
+		t.Error("percent change without a previous value")
+	}
+}
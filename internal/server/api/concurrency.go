@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"sync"
+)
+
+const defaultKlaviyoConcurrency = 4
+
+// forEach calls fn for every index in [0, n) with at most limit calls in
+// flight. The context passed to fn is cancelled once any call fails or the
+// parent context is done (e.g. the HTTP client disconnects), and the first
+// error is returned. Callers write results by index to keep ordering stable.
+func forEach(ctx context.Context, n int, limit int, fn func(ctx context.Context, i int) error) error {
+	if limit < 1 {
+		limit = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	setErr := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	sem := make(chan struct{}, limit)
+	for i := 0; i < n; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			setErr(ctx.Err())
+			break
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := fn(ctx, i)
+			if err != nil {
+				setErr(err)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	return firstErr
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const defaultConversionMetricName = "Placed Order"
+
+// conversionIntegrations are the e-commerce integrations with a Placed Order
+// metric, in order of preference when an account has more than one.
+// Integrations not listed (e.g. custom API events) are used after these.
+var conversionIntegrations = []string{
+	"Shopify",
+	"WooCommerce",
+	"BigCommerce",
+	"Magento",
+	"Magento Two",
+	"Salesforce Commerce Cloud",
+	"PrestaShop",
+}
+
+// conversionCandidateKeywords pick out the metrics listed as candidates when
+// no conversion metric matches.
+var conversionCandidateKeywords = []string{"order", "purchase", "checkout", "transaction"}
+
+// ConversionMetricError is returned when the conversion metric cannot be
+// found, listing the account's metrics that could be used instead.
+type ConversionMetricError struct {
+	// Configured is the account's conversion metric, if set.
+	Configured string
+	Candidates []KlaviyoMetric
+}
+
+func (e *ConversionMetricError) Error() string {
+	candidates := make([]string, len(e.Candidates))
+	for i, candidate := range e.Candidates {
+		candidates[i] = candidate.String()
+	}
+
+	wanted := e.Configured
+	if wanted == "" {
+		wanted = defaultConversionMetricName
+	}
+
+	return fmt.Sprintf("failed to find conversion metric %q, candidates: %s", wanted, strings.Join(candidates, ", "))
+}
+
+// getConversionMetric returns the metric conversions are counted from. The
+// account's configured metric is matched by ID or name, otherwise the Placed
+// Order metric of its e-commerce integration is used.
+func (s *Service) getConversionMetric(ctx context.Context) (KlaviyoMetric, error) {
+	metrics, err := s.listMetrics(ctx, nil)
+	if err != nil {
+		return KlaviyoMetric{}, err
+	}
+
+	configured := s.account(ctx).ConversionMetric
+	metric, ok := findConversionMetric(metrics, configured)
+	if !ok {
+		return KlaviyoMetric{}, &ConversionMetricError{
+			Configured: configured,
+			Candidates: conversionCandidates(metrics),
+		}
+	}
+
+	return metric, nil
+}
+
+func findConversionMetric(metrics []KlaviyoMetric, configured string) (KlaviyoMetric, bool) {
+	name := defaultConversionMetricName
+	if configured != "" {
+		for _, metric := range metrics {
+			if metric.ID == configured {
+				return metric, true
+			}
+		}
+		name = configured
+	}
+
+	matches := []KlaviyoMetric{}
+	for _, metric := range metrics {
+		if strings.EqualFold(metric.Name, name) {
+			matches = append(matches, metric)
+		}
+	}
+	if len(matches) == 0 {
+		return KlaviyoMetric{}, false
+	}
+
+	for _, integration := range conversionIntegrations {
+		for _, metric := range matches {
+			if metric.Integration == integration {
+				return metric, true
+			}
+		}
+	}
+
+	return matches[0], true
+}
+
+func conversionCandidates(metrics []KlaviyoMetric) []KlaviyoMetric {
+	candidates := []KlaviyoMetric{}
+	for _, metric := range metrics {
+		name := strings.ToLower(metric.Name)
+		for _, keyword := range conversionCandidateKeywords {
+			if strings.Contains(name, keyword) {
+				candidates = append(candidates, metric)
+				break
+			}
+		}
+	}
+
+	return candidates
+}
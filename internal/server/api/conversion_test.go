@@ -0,0 +1,64 @@
+package api
+
+import "testing"
+
+func TestFindConversionMetric(t *testing.T) {
+	metrics := []KlaviyoMetric{
+		{ID: "m1", Name: "Placed Order", Integration: "API"},
+		{ID: "m2", Name: "Placed Order", Integration: "WooCommerce"},
+		{ID: "m3", Name: "Placed Order", Integration: "Shopify"},
+		{ID: "m4", Name: "Started Checkout", Integration: "Shopify"},
+		{ID: "m5", Name: "Subscription Renewed", Integration: "API"},
+	}
+
+	tests := []struct {
+		name       string
+		metrics    []KlaviyoMetric
+		configured string
+		wantID     string
+		wantOK     bool
+	}{
+		{name: "default prefers the integration priority", metrics: metrics, wantID: "m3", wantOK: true},
+		{name: "default without a listed integration", metrics: metrics[:1], wantID: "m1", wantOK: true},
+		{name: "configured ID", metrics: metrics, configured: "m1", wantID: "m1", wantOK: true},
+		{name: "configured name", metrics: metrics, configured: "Started Checkout", wantID: "m4", wantOK: true},
+		{name: "configured name is case insensitive", metrics: metrics, configured: "subscription renewed", wantID: "m5", wantOK: true},
+		{name: "configured name not found", metrics: metrics, configured: "Ordered Product"},
+		{name: "no placed order metric", metrics: metrics[3:]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := findConversionMetric(tt.metrics, tt.configured)
+			if ok != tt.wantOK || got.ID != tt.wantID {
+				t.Errorf("findConversionMetric() = %s, %t, want %s, %t", got.ID, ok, tt.wantID, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestConversionCandidates(t *testing.T) {
+	metrics := []KlaviyoMetric{
+		{ID: "m1", Name: "Order Completed"},
+		{ID: "m2", Name: "Opened Email"},
+		{ID: "m3", Name: "Started Checkout"},
+		{ID: "m4", Name: "In-App Purchase"},
+		{ID: "m5", Name: "Viewed Product"},
+	}
+
+	got := conversionCandidates(metrics)
+	ids := []string{}
+	for _, metric := range got {
+		ids = append(ids, metric.ID)
+	}
+
+	want := []string{"m1", "m3", "m4"}
+	if len(ids) != len(want) {
+		t.Fatalf("candidates = %v, want %v", ids, want)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Errorf("candidates = %v, want %v", ids, want)
+		}
+	}
+}
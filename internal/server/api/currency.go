@@ -0,0 +1,93 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+type CurrencyFormat struct {
+	Code      string
+	Symbol    string
+	Suffix    bool
+	Decimal   string
+	Thousands string
+	Decimals  int
+}
+
+// Klaviyo only gives us the account's preferred currency, not a locale, so
+// this is not localized formatting: each currency has one fixed format. Euro
+// is used across locales with different conventions (€1,234.56, 1.234,56 €,
+// 1 234,56 €) and is written with English separators and a leading symbol.
+var currencyFormats = map[string]CurrencyFormat{
+	"EUR": {Symbol: "€", Decimal: ".", Thousands: ",", Decimals: 2},
+	"USD": {Symbol: "$", Decimal: ".", Thousands: ",", Decimals: 2},
+	"GBP": {Symbol: "£", Decimal: ".", Thousands: ",", Decimals: 2},
+	"AUD": {Symbol: "A$", Decimal: ".", Thousands: ",", Decimals: 2},
+	"CAD": {Symbol: "CA$", Decimal: ".", Thousands: ",", Decimals: 2},
+	"NZD": {Symbol: "NZ$", Decimal: ".", Thousands: ",", Decimals: 2},
+	"JPY": {Symbol: "¥", Decimal: ".", Thousands: ",", Decimals: 0},
+	"CHF": {Symbol: "CHF ", Decimal: ".", Thousands: "'", Decimals: 2},
+	"SEK": {Symbol: " kr", Suffix: true, Decimal: ",", Thousands: " ", Decimals: 2},
+	"NOK": {Symbol: " kr", Suffix: true, Decimal: ",", Thousands: " ", Decimals: 2},
+	"DKK": {Symbol: " kr.", Suffix: true, Decimal: ",", Thousands: ".", Decimals: 2},
+	"PLN": {Symbol: " zł", Suffix: true, Decimal: ",", Thousands: " ", Decimals: 2},
+}
+
+const defaultCurrency = "EUR"
+
+var currencyCodeRe = regexp.MustCompile(`^[A-Z]{3}$`)
+
+func isCurrencyCode(code string) bool {
+	return currencyCodeRe.MatchString(code)
+}
+
+// currencyFormat returns the format for an ISO 4217 code, falling back to
+// the code as a prefix for currencies without a known format.
+func currencyFormat(code string) CurrencyFormat {
+	code = strings.ToUpper(code)
+	if code == "" {
+		code = defaultCurrency
+	}
+
+	format, ok := currencyFormats[code]
+	if !ok {
+		format = CurrencyFormat{Symbol: code + " ", Decimal: ".", Thousands: ",", Decimals: 2}
+	}
+	format.Code = code
+
+	return format
+}
+
+// Format formats the value in the currency's fixed format, e.g. €1,234.56 or
+// 1 234,56 kr.
+func (f CurrencyFormat) Format(value float64) string {
+	scale := math.Pow(10, float64(f.Decimals))
+	rounded := math.Round(math.Abs(value)*scale) / scale
+
+	str := fmt.Sprintf("%.*f", f.Decimals, rounded)
+	whole, frac, _ := strings.Cut(str, ".")
+
+	sb := strings.Builder{}
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			sb.WriteString(f.Thousands)
+		}
+		sb.WriteRune(digit)
+	}
+	if frac != "" {
+		sb.WriteString(f.Decimal)
+		sb.WriteString(frac)
+	}
+
+	sign := ""
+	if value < 0 && rounded != 0 {
+		sign = "-"
+	}
+
+	if f.Suffix {
+		return sign + sb.String() + f.Symbol
+	}
+	return sign + f.Symbol + sb.String()
+}
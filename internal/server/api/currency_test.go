@@ -0,0 +1,46 @@
+package api
+
+import "testing"
+
+func TestCurrencyFormat(t *testing.T) {
+	tests := []struct {
+		code  string
+		value float64
+		want  string
+	}{
+		{code: "", value: 1234.5, want: "€1,234.50"},
+		{code: "usd", value: 1234567.891, want: "$1,234,567.89"},
+		{code: "GBP", value: 0, want: "£0.00"},
+		{code: "GBP", value: -12.5, want: "-£12.50"},
+		{code: "GBP", value: -0.001, want: "£0.00"},
+		{code: "JPY", value: 1234.5, want: "¥1,235"},
+		{code: "CHF", value: 1234.5, want: "CHF 1'234.50"},
+		{code: "SEK", value: 1234.5, want: "1 234,50 kr"},
+		{code: "DKK", value: 999.999, want: "1.000,00 kr."},
+		{code: "BRL", value: 1234.5, want: "BRL 1,234.50"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := currencyFormat(tt.code).Format(tt.value); got != tt.want {
+				t.Errorf("currencyFormat(%q).Format(%v) = %s, want %s", tt.code, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsCurrencyCode(t *testing.T) {
+	tests := map[string]bool{
+		"EUR":  true,
+		"BRL":  true,
+		"eur":  false,
+		"EURO": false,
+		"":     false,
+	}
+
+	for code, want := range tests {
+		if got := isCurrencyCode(code); got != want {
+			t.Errorf("isCurrencyCode(%q) = %t, want %t", code, got, want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/oliverbenns/klaviyo-report/generated/klaviyo"
+)
+
+// Engagement is the email funnel of a message. Rates are relative to the
+// delivered count, apart from the bounce rate which is relative to received.
+type Engagement struct {
+	Received        int     `json:"received"`
+	Delivered       int     `json:"delivered"`
+	Opened          int     `json:"opened"`
+	Clicked         int     `json:"clicked"`
+	Bounced         int     `json:"bounced"`
+	Unsubscribed    int     `json:"unsubscribed"`
+	MarkedAsSpam    int     `json:"marked_as_spam"`
+	OpenRate        float64 `json:"open_rate"`
+	ClickRate       float64 `json:"click_rate"`
+	ClickToOpenRate float64 `json:"click_to_open_rate"`
+	BounceRate      float64 `json:"bounce_rate"`
+	UnsubscribeRate float64 `json:"unsubscribe_rate"`
+	SpamRate        float64 `json:"spam_rate"`
+}
+
+type EngagementByID map[string]Engagement
+
+// engagementMetrics are the Klaviyo metrics making up the funnel. Opens and
+// clicks are unique per profile to match the rates shown in Klaviyo.
+var engagementMetrics = []struct {
+	Name        string
+	Measurement klaviyo.MetricAggregateQueryResourceObjectAttributesMeasurements
+	Set         func(e *Engagement, count int)
+}{
+	{"Received Email", "count", func(e *Engagement, count int) { e.Received = count }},
+	{"Opened Email", "unique", func(e *Engagement, count int) { e.Opened = count }},
+	{"Clicked Email", "unique", func(e *Engagement, count int) { e.Clicked = count }},
+	{"Bounced Email", "count", func(e *Engagement, count int) { e.Bounced = count }},
+	{"Unsubscribed", "count", func(e *Engagement, count int) { e.Unsubscribed = count }},
+	{"Marked Email as Spam", "count", func(e *Engagement, count int) { e.MarkedAsSpam = count }},
+}
+
+// getEngagement returns the email funnel grouped by the given dimension,
+// e.g. $message. Metrics the account has never recorded, e.g. no email has
+// been marked as spam yet, are counted as zero.
+func (s *Service) getEngagement(ctx context.Context, by string, rng ReportRange) (EngagementByID, error) {
+	names := make([]string, len(engagementMetrics))
+	for i, metric := range engagementMetrics {
+		names[i] = metric.Name
+	}
+
+	metricIDs, err := s.getKlaviyoMetricIDs(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]map[string]int, len(engagementMetrics))
+	err = forEach(ctx, len(engagementMetrics), s.klaviyoConcurrency(), func(ctx context.Context, i int) error {
+		if metricIDs[i] == "" {
+			return nil
+		}
+
+		metric := engagementMetrics[i]
+		metricCounts, err := s.countMetric(ctx, metricIDs[i], metric.Measurement, by, rng)
+		if err != nil {
+			return fmt.Errorf("failed to get %s counts: %w", metric.Name, err)
+		}
+
+		counts[i] = metricCounts
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	engagement := EngagementByID{}
+	for i, metric := range engagementMetrics {
+		for id, count := range counts[i] {
+			e := engagement[id]
+			metric.Set(&e, count)
+			engagement[id] = e
+		}
+	}
+
+	for id, e := range engagement {
+		engagement[id] = calculateEngagement(e)
+	}
+
+	return engagement, nil
+}
+
+// calculateEngagement derives the delivered count and rates from the event
+// counts. Klaviyo records Received Email for every send, bounces included.
+func calculateEngagement(e Engagement) Engagement {
+	e.Delivered = max(e.Received-e.Bounced, 0)
+
+	if e.Received > 0 {
+		e.BounceRate = float64(e.Bounced) / float64(e.Received)
+	}
+
+	if e.Delivered > 0 {
+		delivered := float64(e.Delivered)
+		e.OpenRate = float64(e.Opened) / delivered
+		e.ClickRate = float64(e.Clicked) / delivered
+		e.UnsubscribeRate = float64(e.Unsubscribed) / delivered
+		e.SpamRate = float64(e.MarkedAsSpam) / delivered
+	}
+
+	if e.Opened > 0 {
+		e.ClickToOpenRate = float64(e.Clicked) / float64(e.Opened)
+	}
+
+	return e
+}
+
+// Add sums the event counts, recalculating the rates.
+func (e Engagement) Add(other Engagement) Engagement {
+	e.Received += other.Received
+	e.Bounced += other.Bounced
+	e.Opened += other.Opened
+	e.Clicked += other.Clicked
+	e.Unsubscribed += other.Unsubscribed
+	e.MarkedAsSpam += other.MarkedAsSpam
+	return calculateEngagement(e)
+}
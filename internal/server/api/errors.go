@@ -0,0 +1,180 @@
+package api
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oliverbenns/klaviyo-report/internal/accounts"
+	"github.com/oliverbenns/klaviyo-report/internal/klaviyoclient"
+)
+
+//go:embed error.html
+var errorContent embed.FS
+
+type ErrorTemplateData struct {
+	Status  int      `json:"status"`
+	Title   string   `json:"title"`
+	Details []string `json:"details,omitempty"`
+}
+
+// badRequestError is an error caused by invalid request parameters.
+type badRequestError struct {
+	err error
+}
+
+func (e badRequestError) Error() string {
+	return e.err.Error()
+}
+
+func (e badRequestError) Unwrap() error {
+	return e.err
+}
+
+// errorResponse maps an error to the status and message shown to the user.
+// Klaviyo errors are mapped so that e.g. a revoked private key shows as
+// forbidden rather than a generic server error.
+func errorResponse(err error) ErrorTemplateData {
+	var apiErr *klaviyoclient.APIError
+	var retryErr *klaviyoclient.RetryError
+	var badRequestErr badRequestError
+	var conversionErr *ConversionMetricError
+
+	switch {
+	case errors.As(err, &badRequestErr):
+		return ErrorTemplateData{
+			Status: http.StatusBadRequest,
+			Title:  badRequestErr.Error(),
+		}
+	case errors.Is(err, accounts.ErrNotFound):
+		return ErrorTemplateData{
+			Status: http.StatusNotFound,
+			Title:  "Account not configured",
+		}
+	case errors.As(err, &conversionErr):
+		data := ErrorTemplateData{
+			Status: http.StatusUnprocessableEntity,
+			Title:  "No Placed Order metric found, set the account's conversion_metric to the name or ID of one of these metrics",
+		}
+		if conversionErr.Configured != "" {
+			data.Title = fmt.Sprintf("Conversion metric %q not found, use the name or ID of one of these metrics", conversionErr.Configured)
+		}
+		for _, candidate := range conversionErr.Candidates {
+			data.Details = append(data.Details, candidate.String())
+		}
+		if len(data.Details) == 0 {
+			data.Details = []string{"The account has no order metrics"}
+		}
+		return data
+	case errors.As(err, &apiErr):
+		data := ErrorTemplateData{}
+		for _, detail := range apiErr.Errors {
+			data.Details = append(data.Details, detail.String())
+		}
+
+		switch {
+		case apiErr.StatusCode == 401 || apiErr.StatusCode == 403:
+			data.Status = http.StatusForbidden
+			data.Title = "Klaviyo denied access to this account, check the private key and its scopes"
+		case apiErr.StatusCode == 404:
+			data.Status = http.StatusNotFound
+			data.Title = "Not found in Klaviyo"
+		case apiErr.StatusCode == 429:
+			data.Status = http.StatusTooManyRequests
+			data.Title = "Klaviyo is rate limiting this account, try again shortly"
+		default:
+			data.Status = http.StatusBadGateway
+			data.Title = fmt.Sprintf("Klaviyo responded with status %d", apiErr.StatusCode)
+		}
+		return data
+	case errors.As(err, &retryErr):
+		if retryErr.StatusCode == 429 {
+			return ErrorTemplateData{
+				Status: http.StatusTooManyRequests,
+				Title:  "Klaviyo is rate limiting this account, try again shortly",
+			}
+		}
+		return ErrorTemplateData{
+			Status: http.StatusServiceUnavailable,
+			Title:  "Klaviyo is currently unavailable, try again shortly",
+		}
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorTemplateData{
+			Status: http.StatusGatewayTimeout,
+			Title:  "Timed out waiting for Klaviyo",
+		}
+	default:
+		return ErrorTemplateData{
+			Status: http.StatusInternalServerError,
+			Title:  "Something went wrong",
+		}
+	}
+}
+
+// logError logs the error at a level matching the response status.
+func (s *Service) logError(msg string, err error, status int) {
+	if status >= 500 {
+		s.Logger.Error(msg, "error", err)
+	} else {
+		s.Logger.Warn(msg, "error", err)
+	}
+}
+
+func setRetryAfter(c *gin.Context, err error) {
+	var retryErr *klaviyoclient.RetryError
+	if errors.As(err, &retryErr) && retryErr.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(retryErr.RetryAfter.Seconds())))
+	}
+}
+
+// renderError logs the error and renders the error page.
+func (s *Service) renderError(c *gin.Context, msg string, err error) {
+	// The client has gone away so there is nobody to respond to.
+	if errors.Is(err, context.Canceled) {
+		s.Logger.Warn(msg, "error", err)
+		c.Abort()
+		return
+	}
+
+	data := errorResponse(err)
+	s.logError(msg, err, data.Status)
+	setRetryAfter(c, err)
+
+	tmpl, err := template.ParseFS(errorContent, "error.html")
+	if err != nil {
+		s.Logger.Error("failed to parse template", "error", err)
+		c.AbortWithStatus(500)
+		return
+	}
+
+	c.Status(data.Status)
+	err = tmpl.Execute(c.Writer, data)
+	if err != nil {
+		s.Logger.Error("failed to execute template", "error", err)
+	}
+	c.Abort()
+}
+
+// renderJSONError logs the error and responds with it as JSON, e.g.
+//
+//	{"error": {"status": 404, "title": "Account not configured"}}
+func (s *Service) renderJSONError(c *gin.Context, msg string, err error) {
+	if errors.Is(err, context.Canceled) {
+		s.Logger.Warn(msg, "error", err)
+		c.Abort()
+		return
+	}
+
+	data := errorResponse(err)
+	s.logError(msg, err, data.Status)
+	setRetryAfter(c, err)
+
+	c.AbortWithStatusJSON(data.Status, gin.H{
+		"error": data,
+	})
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oliverbenns/klaviyo-report/internal/export"
+)
+
+var exportContentTypes = map[string]string{
+	"csv":  "text/csv; charset=utf-8",
+	"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"pdf":  "application/pdf",
+}
+
+func isExportFormat(format string) bool {
+	_, ok := exportContentTypes[format]
+	return ok
+}
+
+// exportKlaviyoReport writes the report in the given format as a file
+// download. CSV and XLSX contain the campaign table only. The file is
+// rendered in full first so a failure is an error response rather than a
+// truncated download.
+func (s *Service) exportKlaviyoReport(c *gin.Context, format string, data KlaviyoReportTemplateData) {
+	buf := bytes.Buffer{}
+
+	var err error
+	switch format {
+	case "csv":
+		err = export.WriteCSV(&buf, campaignTable(data))
+	case "xlsx":
+		err = export.WriteXLSX(&buf, campaignTable(data))
+	case "pdf":
+		err = RenderKlaviyoReportPDF(&buf, data, PDFOptions{
+			Logo:   s.loadLogo(c.Request.Context(), data.Logo),
+			Charts: c.Query("charts") == "1",
+		})
+	}
+	if err != nil {
+		s.renderError(c, "failed to write export", fmt.Errorf("failed to write %s export: %w", format, err))
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, exportFilename(data, format)))
+	c.Data(200, exportContentTypes[format], buf.Bytes())
+}
+
+// exportFilename is e.g. klaviyo-report_brand_2024-01-01_2024-01-31.csv
+func exportFilename(data KlaviyoReportTemplateData, ext string) string {
+	name := export.Slug(data.AccountName)
+	if name == "" {
+		name = export.Slug(data.AccountID)
+	}
+
+	return fmt.Sprintf("klaviyo-report_%s_%s_%s.%s", name, data.Range.From.Format(dateLayout), data.Range.LastDay().Format(dateLayout), ext)
+}
+
+func campaignTable(data KlaviyoReportTemplateData) export.Table {
+	table := export.Table{
+		Name: "Campaigns",
+		Headers: []string{
+			"Name",
+			"Channel",
+			"Tags",
+			"Send Time",
+			"Subject",
+			"Preview Text",
+			"Included Audiences",
+			"Excluded Audiences",
+			"Total Recipients",
+			"Recipient Source",
+			"Order Status",
+			"Orders Placed",
+			"Revenue",
+			"Conversion Rate",
+			"Conversion Value",
+			"Revenue Per Recipient",
+			"Delivered",
+			"Open Rate",
+			"Click Rate",
+			"Click to Open Rate",
+			"Bounce Rate",
+			"Unsubscribe Rate",
+			"Spam Rate",
+			"SMS Sent",
+			"SMS Delivered",
+			"SMS Clicked",
+			"SMS Failed",
+			"SMS Unsubscribed",
+			"SMS Cost",
+		},
+	}
+
+	for _, campaign := range data.Campaigns {
+		table.Rows = append(table.Rows, append([]any{
+			campaign.Name,
+			campaign.Channel,
+			strings.Join(campaign.Tags, ", "),
+			exportTime(campaign.SendTime),
+			campaign.Subject,
+			campaign.PreviewText,
+			audienceNames(campaign.Audiences.Included),
+			audienceNames(campaign.Audiences.Excluded),
+			campaign.TotalRecipients,
+			campaign.RecipientSource,
+			campaign.OrderStatus,
+			campaign.OrdersPlaced,
+			campaign.Revenue,
+			campaign.ConversionRate,
+			campaign.ConversionValue,
+			campaign.RevenuePerRecipient,
+			campaign.Delivered,
+			campaign.OpenRate,
+			campaign.ClickRate,
+			campaign.ClickToOpenRate,
+			campaign.BounceRate,
+			campaign.UnsubscribeRate,
+			campaign.SpamRate,
+		}, smsCells(campaign.SMS)...))
+	}
+
+	table.Rows = append(table.Rows, append([]any{
+		"Total",
+		"",
+		"",
+		"",
+		"",
+		"",
+		"",
+		"",
+		data.Totals.TotalRecipients,
+		"",
+		"",
+		data.Totals.OrdersPlaced,
+		data.Totals.Revenue,
+		data.Totals.ConversionRate,
+		data.Totals.ConversionValue,
+		data.Totals.RevenuePerRecipient,
+		data.Totals.Delivered,
+		data.Totals.OpenRate,
+		data.Totals.ClickRate,
+		data.Totals.ClickToOpenRate,
+		data.Totals.BounceRate,
+		data.Totals.UnsubscribeRate,
+		data.Totals.SpamRate,
+	}, smsCells(nil)...))
+
+	return table
+}
+
+func exportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func audienceNames(audiences []CampaignAudience) string {
+	names := []string{}
+	for _, audience := range audiences {
+		names = append(names, audience.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// smsCells are the SMS columns of a campaign, empty for email campaigns and
+// the cost only when the account has segment costs configured.
+func smsCells(sms *SMSEngagement) []any {
+	if sms == nil {
+		return []any{nil, nil, nil, nil, nil, nil}
+	}
+
+	var cost any
+	if sms.HasCost {
+		cost = sms.Cost
+	}
+	return []any{sms.Sent, sms.Delivered, sms.Clicked, sms.Failed, sms.Unsubscribed, cost}
+}
@@ -0,0 +1,235 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/oliverbenns/klaviyo-report/generated/klaviyo"
+	"github.com/oliverbenns/klaviyo-report/internal/conv"
+	"github.com/oliverbenns/klaviyo-report/internal/klaviyoclient"
+)
+
+type KlaviyoReportTemplateFlowMessage struct {
+	Name                string  `json:"name"`
+	Channel             string  `json:"channel"`
+	TotalRecipients     int     `json:"total_recipients"`
+	OrdersPlaced        int     `json:"orders_placed"`
+	Revenue             float64 `json:"revenue"`
+	ConversionRate      float64 `json:"conversion_rate"`
+	RevenuePerRecipient float64 `json:"revenue_per_recipient"`
+}
+
+type KlaviyoReportTemplateFlow struct {
+	Name                string                             `json:"name"`
+	Status              string                             `json:"status"`
+	TotalRecipients     int                                `json:"total_recipients"`
+	OrdersPlaced        int                                `json:"orders_placed"`
+	Revenue             float64                            `json:"revenue"`
+	ConversionRate      float64                            `json:"conversion_rate"`
+	RevenuePerRecipient float64                            `json:"revenue_per_recipient"`
+	Messages            []KlaviyoReportTemplateFlowMessage `json:"messages"`
+}
+
+// getKlaviyoReportFlows builds the flow section of the report. Flows are
+// reported as a whole (by $attributed_flow / $flow) and per flow message
+// (by $attributed_message / $message).
+func (s *Service) getKlaviyoReportFlows(ctx context.Context, rng ReportRange, messageMetrics MetricsByCampaignID, messageRecipients RecipientsByID) ([]KlaviyoReportTemplateFlow, error) {
+	pages, err := paginate(func(cursor *string) (*klaviyo.GetFlowResponseCollectionCompoundDocument, error) {
+		params := &klaviyo.GetFlowsParams{
+			Revision:   "2023-12-15",
+			Filter:     conv.Ptr("equals(archived,false)"),
+			PageCursor: cursor,
+		}
+		return cacheResponse(ctx, s, "flows", params, func() (*klaviyo.GetFlowResponseCollectionCompoundDocument, error) {
+			res, err := s.klaviyo(ctx).GetFlowsWithResponse(ctx, params)
+			if err != nil {
+				return nil, err
+			}
+			return klaviyoclient.CheckResponse(res.StatusCode(), res.Body, res.JSON200)
+		})
+	}, func(page *klaviyo.GetFlowResponseCollectionCompoundDocument) klaviyo.CollectionLinks {
+		return page.Links
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flows: %w", err)
+	}
+
+	flowMetrics, err := s.getMetrics(ctx, "$attributed_flow", rng)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flow metrics: %w", err)
+	}
+
+	flowRecipients, err := s.getRecipients(ctx, "$flow", rng)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flow recipients: %w", err)
+	}
+
+	templateFlows := []KlaviyoReportTemplateFlow{}
+	flowIDs := []string{}
+	for _, page := range pages {
+		for _, flow := range page.Data {
+			metric := flowMetrics[flow.Id]
+			recipientCount := flowRecipients[flow.Id]
+
+			// Skip flows with no activity in the range rather than fetching
+			// their actions and messages.
+			if recipientCount == 0 && metric.Count == 0 {
+				continue
+			}
+
+			templateFlow := calculateFlow(conv.Val(flow.Attributes.Name), metric, recipientCount)
+			templateFlow.Status = conv.Val(flow.Attributes.Status)
+			templateFlow.Messages = []KlaviyoReportTemplateFlowMessage{}
+			templateFlows = append(templateFlows, templateFlow)
+			flowIDs = append(flowIDs, flow.Id)
+		}
+	}
+
+	flowActionIDs := make([][]string, len(flowIDs))
+	err = forEach(ctx, len(flowIDs), s.klaviyoConcurrency(), func(ctx context.Context, i int) error {
+		actionIDs, err := s.getFlowSendActionIDs(ctx, flowIDs[i])
+		if err != nil {
+			return err
+		}
+
+		flowActionIDs[i] = actionIDs
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Messages are fetched for every flow's actions at once so the
+	// concurrency limit applies across flows.
+	type flowAction struct {
+		Flow int
+		ID   string
+	}
+	actions := []flowAction{}
+	for i, actionIDs := range flowActionIDs {
+		for _, actionID := range actionIDs {
+			actions = append(actions, flowAction{Flow: i, ID: actionID})
+		}
+	}
+
+	actionMessages := make([]*klaviyo.GetFlowMessageResponseCollection, len(actions))
+	err = forEach(ctx, len(actions), s.klaviyoConcurrency(), func(ctx context.Context, i int) error {
+		messages, err := s.getFlowActionMessages(ctx, actions[i].ID)
+		if err != nil {
+			return err
+		}
+
+		actionMessages[i] = messages
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, action := range actions {
+		templateFlow := &templateFlows[action.Flow]
+		for _, message := range actionMessages[i].Data {
+			templateMessage := calculateFlowMessage(message.Attributes.Name, messageMetrics[message.Id], messageRecipients[message.Id])
+			templateMessage.Channel = message.Attributes.Channel
+			templateFlow.Messages = append(templateFlow.Messages, templateMessage)
+		}
+	}
+
+	return templateFlows, nil
+}
+
+// flowSendActionTypes are the flow actions that send a message. Other
+// actions, e.g. time delays and splits, have no messages.
+var flowSendActionTypes = map[string]bool{
+	"SEND_EMAIL": true,
+	"SEND_SMS":   true,
+}
+
+// getFlowSendActionIDs returns the IDs of a flow's actions that send a
+// message.
+func (s *Service) getFlowSendActionIDs(ctx context.Context, flowID string) ([]string, error) {
+	actionPages, err := paginate(func(cursor *string) (*klaviyo.GetFlowActionResponseCollection, error) {
+		params := &klaviyo.GetFlowFlowActionsParams{
+			Revision:   "2023-12-15",
+			PageCursor: cursor,
+		}
+		return cacheResponse(ctx, s, "flow-actions", []any{flowID, params}, func() (*klaviyo.GetFlowActionResponseCollection, error) {
+			res, err := s.klaviyo(ctx).GetFlowFlowActionsWithResponse(ctx, flowID, params)
+			if err != nil {
+				return nil, err
+			}
+			return klaviyoclient.CheckResponse(res.StatusCode(), res.Body, res.JSON200)
+		})
+	}, func(page *klaviyo.GetFlowActionResponseCollection) klaviyo.CollectionLinks {
+		return page.Links
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flow actions: %w", err)
+	}
+
+	actionIDs := []string{}
+	for _, actionPage := range actionPages {
+		for _, action := range actionPage.Data {
+			if flowSendActionTypes[conv.Val(action.Attributes.ActionType)] {
+				actionIDs = append(actionIDs, action.Id)
+			}
+		}
+	}
+
+	return actionIDs, nil
+}
+
+func (s *Service) getFlowActionMessages(ctx context.Context, actionID string) (*klaviyo.GetFlowMessageResponseCollection, error) {
+	// Flow action messages are not cursor paginated so request the maximum
+	// page size.
+	params := &klaviyo.GetFlowActionMessagesParams{
+		Revision: "2023-12-15",
+		PageSize: conv.Ptr(100),
+	}
+	res, err := cacheResponse(ctx, s, "flow-action-messages", []any{actionID, params}, func() (*klaviyo.GetFlowMessageResponseCollection, error) {
+		res, err := s.klaviyo(ctx).GetFlowActionMessagesWithResponse(ctx, actionID, params)
+		if err != nil {
+			return nil, err
+		}
+		return klaviyoclient.CheckResponse(res.StatusCode(), res.Body, res.JSON200)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flow action messages: %w", err)
+	}
+
+	return res, nil
+}
+
+func calculateFlow(name string, metric Metric, recipientCount int) KlaviyoReportTemplateFlow {
+	flow := KlaviyoReportTemplateFlow{}
+	flow.Name = name
+	flow.TotalRecipients = recipientCount
+	flow.OrdersPlaced = metric.Count
+	flow.Revenue = metric.Revenue
+
+	if recipientCount == 0 {
+		return flow
+	}
+
+	flow.ConversionRate = float64(metric.Count) / float64(recipientCount)
+	flow.RevenuePerRecipient = metric.Revenue / float64(recipientCount)
+
+	return flow
+}
+
+func calculateFlowMessage(name string, metric Metric, recipientCount int) KlaviyoReportTemplateFlowMessage {
+	message := KlaviyoReportTemplateFlowMessage{}
+	message.Name = name
+	message.TotalRecipients = recipientCount
+	message.OrdersPlaced = metric.Count
+	message.Revenue = metric.Revenue
+
+	if recipientCount == 0 {
+		return message
+	}
+
+	message.ConversionRate = float64(metric.Count) / float64(recipientCount)
+	message.RevenuePerRecipient = metric.Revenue / float64(recipientCount)
+
+	return message
+}
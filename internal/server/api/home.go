@@ -8,6 +8,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/oliverbenns/klaviyo-report/generated/klaviyo"
+	"github.com/oliverbenns/klaviyo-report/internal/accounts"
+	"github.com/oliverbenns/klaviyo-report/internal/klaviyoclient"
 )
 
 //go:embed home.html
@@ -23,28 +25,22 @@ type HomeTemplateData struct {
 }
 
 func (s *Service) GetHome(c *gin.Context) {
-	res, err := s.KlaviyoClient.GetAccountsWithResponse(c.Request.Context(), &klaviyo.GetAccountsParams{
-		Revision: "2023-12-15",
-	})
+	accountList, err := s.Accounts.List(c.Request.Context())
 	if err != nil {
-		s.Logger.Error("failed to get accounts", "error", err)
-		c.AbortWithStatus(500)
+		s.renderError(c, "failed to list accounts", err)
 		return
 	}
 
-	// Even though we are listing and linking to accounts we are only supporting
-	// 1 API key at the minute which is at the account level therefore this
-	// kind of doesn't make too much sense as only 1 of the reports is going to work.
 	homeAccounts := []HomeTemplateAccount{}
-	for _, account := range res.JSON200.Data {
-		reportURLStr := fmt.Sprintf("/reports/%s", account.Id)
+	for _, account := range accountList {
+		reportURLStr := fmt.Sprintf("/reports/%s", account.ID)
 		reportURL, _ := url.Parse(reportURLStr)
 		q := url.Values{}
 		q.Set("api_key", s.ApiKey)
 		reportURL.RawQuery = q.Encode()
 
 		homeAccounts = append(homeAccounts, HomeTemplateAccount{
-			Name: account.Attributes.ContactInformation.OrganizationName,
+			Name: s.getAccountName(c, account),
 			URL:  reportURL.String(),
 		})
 	}
@@ -68,3 +64,34 @@ func (s *Service) GetHome(c *gin.Context) {
 	c.Status(200)
 	return
 }
+
+// getAccountName returns the configured account name, falling back to the
+// organization name in Klaviyo and then the account ID.
+func (s *Service) getAccountName(c *gin.Context, account accounts.Account) string {
+	if account.Name != "" {
+		return account.Name
+	}
+
+	ctx, err := s.withKlaviyoAccount(c, account)
+	if err != nil {
+		s.Logger.Warn("failed to get account name", "account_id", account.ID, "error", err)
+		return account.ID
+	}
+
+	params := &klaviyo.GetAccountParams{
+		Revision: "2023-12-15",
+	}
+	res, err := cacheResponse(ctx, s, "account", []any{account.ID, params}, func() (*klaviyo.GetAccountRes, error) {
+		res, err := s.klaviyo(ctx).GetAccountWithResponse(ctx, account.ID, params)
+		if err != nil {
+			return nil, err
+		}
+		return klaviyoclient.CheckResponse(res.StatusCode(), res.Body, res.JSON200)
+	})
+	if err != nil || res == nil {
+		s.Logger.Warn("failed to get account name", "account_id", account.ID, "error", err)
+		return account.ID
+	}
+
+	return res.Data.Attributes.ContactInformation.OrganizationName
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/oliverbenns/klaviyo-report/generated/klaviyo"
+)
+
+// ReportKPIs are the account level headline numbers. Attributed figures
+// cover both campaigns and flows.
+type ReportKPIs struct {
+	AttributedRevenue   float64 `json:"attributed_revenue"`
+	OrdersPlaced        int     `json:"orders_placed"`
+	TotalRecipients     int     `json:"total_recipients"`
+	ConversionRate      float64 `json:"conversion_rate"`
+	AverageOrderValue   float64 `json:"average_order_value"`
+	RevenuePerRecipient float64 `json:"revenue_per_recipient"`
+	// StoreRevenue is the revenue of every conversion, attributed or not.
+	StoreRevenue float64 `json:"store_revenue"`
+	StoreOrders  int     `json:"store_orders"`
+	// RevenueShare is AttributedRevenue as a share of StoreRevenue.
+	RevenueShare float64 `json:"revenue_share"`
+}
+
+// getStoreTotal returns the conversion metric count and revenue of every
+// event in the range, attributed or not.
+func (s *Service) getStoreTotal(ctx context.Context, rng ReportRange) (Metric, error) {
+	conversionMetric, err := s.getConversionMetric(ctx)
+	if err != nil {
+		return Metric{}, err
+	}
+
+	aggResults, _, err := s.queryMetricAggregates(ctx, conversionMetric.ID, []klaviyo.MetricAggregateQueryResourceObjectAttributesMeasurements{
+		"count",
+		"sum_value",
+	}, nil, "month", rng)
+	if err != nil {
+		return Metric{}, err
+	}
+
+	total := Metric{}
+	for _, aggResult := range aggResults {
+		count, err := sumMeasurement(aggResult.Measurements["count"])
+		if err != nil {
+			return Metric{}, fmt.Errorf("failed to sum metric count aggregate measurements: %w", err)
+		}
+
+		revenue, err := sumMeasurement(aggResult.Measurements["sum_value"])
+		if err != nil {
+			return Metric{}, fmt.Errorf("failed to sum metric sum_value aggregate measurements: %w", err)
+		}
+
+		total.Count += int(count)
+		total.Revenue += revenue
+	}
+
+	return total, nil
+}
+
+// AttributedTotal sums the metrics of every message, leaving out events
+// without one, e.g. unattributed orders.
+func (m MetricsByCampaignID) AttributedTotal() Metric {
+	total := Metric{}
+	for id, metric := range m {
+		if id == "" {
+			continue
+		}
+		total.Count += metric.Count
+		total.Revenue += metric.Revenue
+	}
+	return total
+}
+
+// Total sums the recipients of every message, leaving out events without
+// one.
+func (r RecipientsByID) Total() int {
+	total := 0
+	for id, count := range r {
+		if id == "" {
+			continue
+		}
+		total += count
+	}
+	return total
+}
+
+// calculateKPIs derives the headline numbers. Recipients are those of every
+// campaign and flow message on any channel, like the attributed metrics, so
+// they don't depend on the report's channel.
+func calculateKPIs(attributed Metric, store Metric, recipients int) ReportKPIs {
+	kpis := ReportKPIs{
+		AttributedRevenue: attributed.Revenue,
+		OrdersPlaced:      attributed.Count,
+		TotalRecipients:   recipients,
+		AverageOrderValue: averageOrderValue(attributed),
+		StoreRevenue:      store.Revenue,
+		StoreOrders:       store.Count,
+	}
+
+	if kpis.TotalRecipients > 0 {
+		kpis.ConversionRate = float64(kpis.OrdersPlaced) / float64(kpis.TotalRecipients)
+		kpis.RevenuePerRecipient = kpis.AttributedRevenue / float64(kpis.TotalRecipients)
+	}
+
+	if kpis.StoreRevenue > 0 {
+		kpis.RevenueShare = kpis.AttributedRevenue / kpis.StoreRevenue
+	}
+
+	return kpis
+}
@@ -0,0 +1,33 @@
+package api
+
+import "testing"
+
+func TestCalculateKPIs(t *testing.T) {
+	recipients := RecipientsByID{"email": 800, "sms": 200, "": 50}
+
+	kpis := calculateKPIs(Metric{Count: 20, Revenue: 1000}, Metric{Count: 80, Revenue: 4000}, recipients.Total())
+	if kpis.TotalRecipients != 1000 {
+		t.Errorf("TotalRecipients = %d, want 1000", kpis.TotalRecipients)
+	}
+
+	rates := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"conversion rate", kpis.ConversionRate, 0.02},
+		{"average order value", kpis.AverageOrderValue, 50},
+		{"revenue per recipient", kpis.RevenuePerRecipient, 1},
+		{"revenue share", kpis.RevenueShare, 0.25},
+	}
+	for _, rate := range rates {
+		if !floatEqual(rate.got, rate.want) {
+			t.Errorf("%s = %v, want %v", rate.name, rate.got, rate.want)
+		}
+	}
+
+	empty := calculateKPIs(Metric{}, Metric{}, 0)
+	if empty.ConversionRate != 0 || empty.AverageOrderValue != 0 || empty.RevenueShare != 0 {
+		t.Errorf("KPIs without activity = %+v, want 0", empty)
+	}
+}
@@ -2,11 +2,14 @@ package api
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/oliverbenns/klaviyo-report/generated/klaviyo"
 	"github.com/oliverbenns/klaviyo-report/internal/conv"
+	"github.com/oliverbenns/klaviyo-report/internal/klaviyoclient"
 )
 
 // Openapi generator does not generate this - uses inline struct.
@@ -23,6 +26,13 @@ type MetricAggAttributes struct {
 	Timezone     *string                                                            `json:"timezone,omitempty"`
 }
 
+// Openapi generator does not generate the collection links used to paginate
+// metric aggregates.
+type MetricAggPage struct {
+	klaviyo.PostMetricAggregateRes
+	Links klaviyo.CollectionLinks `json:"links"`
+}
+
 type Metric struct {
 	Count   int
 	Revenue float64
@@ -30,61 +40,198 @@ type Metric struct {
 
 type MetricsByCampaignID map[string]Metric
 
-func (s *Service) getMetrics(ctx context.Context, by string) (MetricsByCampaignID, error) {
-	now := time.Now().UTC()
-	oneMonthAgo := now.AddDate(0, 0, -30)
+type RecipientsByID map[string]int
 
-	metricsRes, err := s.KlaviyoClient.GetMetricsWithResponse(ctx, &klaviyo.GetMetricsParams{
-		Revision: "2023-12-15",
-		Filter:   conv.Ptr("equals(integration.name,'Shopify')"),
+// KlaviyoMetric is the subset of a Klaviyo metric used to find metrics.
+type KlaviyoMetric struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Integration string `json:"integration"`
+}
+
+// errMetricNotFound is returned when an account has no metric with a name,
+// e.g. it has never recorded the event.
+var errMetricNotFound = errors.New("metric not found")
+
+func (m KlaviyoMetric) String() string {
+	return fmt.Sprintf("%s (%s, %s)", m.Name, m.Integration, m.ID)
+}
+
+func (s *Service) getMetricID(ctx context.Context, filter *string, name string) (string, error) {
+	metrics, err := s.listMetrics(ctx, filter)
+	if err != nil {
+		return "", err
+	}
+
+	metricID := findMetricIDs(metrics, []string{name})[0]
+	if metricID == "" {
+		return "", fmt.Errorf("failed to find %s metric: %w", name, errMetricNotFound)
+	}
+
+	return metricID, nil
+}
+
+// getKlaviyoMetricIDs returns the IDs of Klaviyo's own metrics, e.g. Opened
+// Email, in the same order as names, listing the account's metrics once.
+// Metrics the account has never recorded have an empty ID.
+func (s *Service) getKlaviyoMetricIDs(ctx context.Context, names []string) ([]string, error) {
+	metrics, err := s.listMetrics(ctx, conv.Ptr("equals(integration.name,'Klaviyo')"))
+	if err != nil {
+		return nil, err
+	}
+
+	metricIDs := findMetricIDs(metrics, names)
+	for i, metricID := range metricIDs {
+		if metricID == "" {
+			s.Logger.Debug("skipping missing metric", "metric", names[i])
+		}
+	}
+
+	return metricIDs, nil
+}
+
+func findMetricIDs(metrics []KlaviyoMetric, names []string) []string {
+	metricIDs := make([]string, len(names))
+	for i, name := range names {
+		for _, metric := range metrics {
+			if metric.Name == name {
+				metricIDs[i] = metric.ID
+				break
+			}
+		}
+	}
+	return metricIDs
+}
+
+func (s *Service) listMetrics(ctx context.Context, filter *string) ([]KlaviyoMetric, error) {
+	pages, err := paginate(func(cursor *string) (*klaviyo.GetMetricResponseCollection, error) {
+		params := &klaviyo.GetMetricsParams{
+			Revision:   "2023-12-15",
+			Filter:     filter,
+			PageCursor: cursor,
+		}
+		return cacheResponse(ctx, s, "metrics", params, func() (*klaviyo.GetMetricResponseCollection, error) {
+			res, err := s.klaviyo(ctx).GetMetricsWithResponse(ctx, params)
+			if err != nil {
+				return nil, err
+			}
+			return klaviyoclient.CheckResponse(res.StatusCode(), res.Body, res.JSON200)
+		})
+	}, func(page *klaviyo.GetMetricResponseCollection) klaviyo.CollectionLinks {
+		return page.Links
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get metrics: %w", err)
 	}
 
-	placedOrderMetricID := ""
-	for _, metric := range metricsRes.JSON200.Data {
-		if *metric.Attributes.Name == "Placed Order" {
-			placedOrderMetricID = metric.Id
-			break
+	metrics := []KlaviyoMetric{}
+	for _, page := range pages {
+		for _, metric := range page.Data {
+			integration := ""
+			if metric.Attributes.Integration != nil {
+				integration, _ = (*metric.Attributes.Integration)["name"].(string)
+			}
+
+			metrics = append(metrics, KlaviyoMetric{
+				ID:          metric.Id,
+				Name:        conv.Val(metric.Attributes.Name),
+				Integration: integration,
+			})
 		}
 	}
 
-	if placedOrderMetricID == "" {
-		return nil, fmt.Errorf("failed to find placed order metric")
-	}
+	return metrics, nil
+}
 
+// queryMetricAggregates returns the metric aggregate rows grouped by the given
+// dimensions, with a measurement per interval bucket, and the bucket dates.
+// Each row's Dimensions are in the same order as by.
+func (s *Service) queryMetricAggregates(ctx context.Context, metricID string, measurements []klaviyo.MetricAggregateQueryResourceObjectAttributesMeasurements, by []string, interval string, rng ReportRange) ([]klaviyo.MetricAggregateRowDTO, []time.Time, error) {
 	params := &klaviyo.QueryMetricAggregatesParams{
 		Revision: "2023-12-15",
 	}
 
-	body := klaviyo.QueryMetricAggregatesJSONRequestBody{
-		Data: klaviyo.MetricAggregateQueryResourceObject{
-			Type: "metric-aggregate",
-			Attributes: MetricAggAttributes{
-				MetricId: placedOrderMetricID,
-				Measurements: []klaviyo.MetricAggregateQueryResourceObjectAttributesMeasurements{
-					"count",
-					"sum_value",
-				},
-				By: &[]klaviyo.MetricAggregateQueryResourceObjectAttributesBy{
-					klaviyo.MetricAggregateQueryResourceObjectAttributesBy(by),
-				},
-				Interval: conv.Ptr(klaviyo.MetricAggregateQueryResourceObjectAttributesInterval("month")),
-				Filter: []string{
-					fmt.Sprintf("greater-or-equal(datetime,%s),less-than(datetime,%s)", oneMonthAgo.Format(time.RFC3339), now.Format(time.RFC3339)),
+	// No dimensions aggregates every event of the metric.
+	var aggBy *[]klaviyo.MetricAggregateQueryResourceObjectAttributesBy
+	if len(by) > 0 {
+		aggBy = &[]klaviyo.MetricAggregateQueryResourceObjectAttributesBy{}
+		for _, dimension := range by {
+			*aggBy = append(*aggBy, klaviyo.MetricAggregateQueryResourceObjectAttributesBy(dimension))
+		}
+	}
+
+	pages, err := paginate(func(cursor *string) (*MetricAggPage, error) {
+		body := klaviyo.QueryMetricAggregatesJSONRequestBody{
+			Data: klaviyo.MetricAggregateQueryResourceObject{
+				Type: "metric-aggregate",
+				Attributes: MetricAggAttributes{
+					MetricId:     metricID,
+					Measurements: measurements,
+					By:           aggBy,
+					Interval:     conv.Ptr(klaviyo.MetricAggregateQueryResourceObjectAttributesInterval(interval)),
+					Filter: []string{
+						rng.Filter("datetime"),
+					},
+					Timezone:   conv.Ptr(rng.Timezone()),
+					PageCursor: cursor,
 				},
 			},
-		},
+		}
+
+		return cacheResponse(ctx, s, "metric-aggregates", body, func() (*MetricAggPage, error) {
+			res, err := s.klaviyo(ctx).QueryMetricAggregatesWithResponse(ctx, params, body)
+			if err != nil {
+				return nil, err
+			}
+			_, err = klaviyoclient.CheckResponse(res.StatusCode(), res.Body, res.JSON200)
+			if err != nil {
+				return nil, err
+			}
+
+			page := &MetricAggPage{}
+			err = json.Unmarshal(res.Body, page)
+			if err != nil {
+				return nil, fmt.Errorf("failed to unmarshal metric aggregates: %w", err)
+			}
+			return page, nil
+		})
+	}, func(page *MetricAggPage) klaviyo.CollectionLinks {
+		return page.Links
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get metric aggregates: %w", err)
+	}
+
+	rows := []klaviyo.MetricAggregateRowDTO{}
+	dates := []time.Time{}
+	for _, page := range pages {
+		rows = append(rows, page.Data.Attributes.Data...)
+		// Every page has the same buckets.
+		dates = page.Data.Attributes.Dates
 	}
 
-	aggRes, err := s.KlaviyoClient.QueryMetricAggregatesWithResponse(ctx, params, body)
+	return rows, dates, nil
+}
+
+// getMetrics returns the conversion metric (e.g. Placed Order) count and
+// revenue grouped by the given dimension, e.g. $attributed_message or
+// $attributed_flow.
+func (s *Service) getMetrics(ctx context.Context, by string, rng ReportRange) (MetricsByCampaignID, error) {
+	conversionMetric, err := s.getConversionMetric(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	aggResults, _, err := s.queryMetricAggregates(ctx, conversionMetric.ID, []klaviyo.MetricAggregateQueryResourceObjectAttributesMeasurements{
+		"count",
+		"sum_value",
+	}, []string{by}, "month", rng)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get metric aggregates: %w", err)
+		return nil, err
 	}
 
 	metrics := MetricsByCampaignID{}
-	for _, aggResult := range aggRes.JSON200.Data.Attributes.Data {
+	for _, aggResult := range aggResults {
 		campaignID := aggResult.Dimensions[0]
 
 		count := aggResult.Measurements["count"]
@@ -108,22 +255,96 @@ func (s *Service) getMetrics(ctx context.Context, by string) (MetricsByCampaignI
 	return metrics, nil
 }
 
+// recipientMetrics count a message's recipients per channel, so flows
+// sending both email and SMS have recipients for the orders of either.
+var recipientMetrics = []string{"Received Email", "Received SMS"}
+
+// getRecipients returns the Received Email and Received SMS count grouped by
+// the given dimension, e.g. $message or $flow. Metrics the account has never
+// recorded, e.g. it has never sent an SMS, are counted as zero.
+func (s *Service) getRecipients(ctx context.Context, by string, rng ReportRange) (RecipientsByID, error) {
+	metricIDs, err := s.getKlaviyoMetricIDs(ctx, recipientMetrics)
+	if err != nil {
+		return nil, err
+	}
+
+	recipients := RecipientsByID{}
+	for _, metricID := range metricIDs {
+		if metricID == "" {
+			continue
+		}
+
+		counts, err := s.countMetric(ctx, metricID, "count", by, rng)
+		if err != nil {
+			return nil, err
+		}
+
+		for id, count := range counts {
+			recipients[id] += count
+		}
+	}
+
+	return recipients, nil
+}
+
+// countMetric returns the count (or unique count) of a metric's events
+// grouped by the given dimension.
+func (s *Service) countMetric(ctx context.Context, metricID string, measurement klaviyo.MetricAggregateQueryResourceObjectAttributesMeasurements, by string, rng ReportRange) (map[string]int, error) {
+	aggResults, _, err := s.queryMetricAggregates(ctx, metricID, []klaviyo.MetricAggregateQueryResourceObjectAttributesMeasurements{
+		measurement,
+	}, []string{by}, "month", rng)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int{}
+	for _, aggResult := range aggResults {
+		id := aggResult.Dimensions[0]
+
+		totalCount, err := sumMeasurement(aggResult.Measurements[string(measurement)])
+		if err != nil {
+			return nil, fmt.Errorf("failed to sum metric %s aggregate measurements: %w", measurement, err)
+		}
+
+		counts[id] = int(totalCount)
+	}
+
+	return counts, nil
+}
+
 // sumMeasurement sums the measurements in a metric aggregate response.
-// We query over 30 days but sometimes there are 2 results (31 day months?)
+// Ranges can span several monthly intervals (in the range's timezone) so
+// there may be multiple results.
 func sumMeasurement(measurements interface{}) (float64, error) {
-	vals, ok := measurements.([]interface{})
-	if !ok {
-		return 0, fmt.Errorf("failed to convert metric aggregate measurement")
+	vals, err := measurementValues(measurements)
+	if err != nil {
+		return 0, err
 	}
 
 	sum := 0.
 	for _, val := range vals {
+		sum += val
+	}
+
+	return sum, nil
+}
+
+// measurementValues returns the measurement of each interval bucket in a
+// metric aggregate response.
+func measurementValues(measurements interface{}) ([]float64, error) {
+	vals, ok := measurements.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("failed to convert metric aggregate measurement")
+	}
+
+	realVals := make([]float64, len(vals))
+	for i, val := range vals {
 		realVal, ok := val.(float64)
 		if !ok {
-			return 0, fmt.Errorf("failed to convert metric aggregate measurement to float64")
+			return nil, fmt.Errorf("failed to convert metric aggregate measurement to float64")
 		}
-		sum += realVal
+		realVals[i] = realVal
 	}
 
-	return sum, nil
+	return realVals, nil
 }
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindMetricIDs(t *testing.T) {
+	metrics := []KlaviyoMetric{
+		{ID: "m1", Name: "Received Email"},
+		{ID: "m2", Name: "Opened Email"},
+		{ID: "m3", Name: "Opened Email"},
+	}
+
+	tests := []struct {
+		name  string
+		names []string
+		want  []string
+	}{
+		{name: "in the order of names", names: []string{"Opened Email", "Received Email"}, want: []string{"m2", "m1"}},
+		{name: "missing metrics are empty", names: []string{"Received Email", "Marked Email as Spam"}, want: []string{"m1", ""}},
+		{name: "names are case sensitive", names: []string{"received email"}, want: []string{""}},
+		{name: "no names", names: nil, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findMetricIDs(metrics, tt.names)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") || len(got) != len(tt.want) {
+				t.Errorf("findMetricIDs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
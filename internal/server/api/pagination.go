@@ -0,0 +1,63 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/oliverbenns/klaviyo-report/generated/klaviyo"
+)
+
+// Upper bound on pages fetched for a single listing so that a bad cursor
+// can't loop forever.
+const maxPages = 100
+
+// paginate fetches every page of a Klaviyo collection by following the
+// links.next cursor. fetch is called with a nil cursor for the first page.
+func paginate[T any](fetch func(cursor *string) (*T, error), links func(page *T) klaviyo.CollectionLinks) ([]*T, error) {
+	pages := []*T{}
+	var cursor *string
+
+	for i := 0; i < maxPages; i++ {
+		page, err := fetch(cursor)
+		if err != nil {
+			return nil, err
+		}
+		if page == nil {
+			return pages, nil
+		}
+		pages = append(pages, page)
+
+		next := links(page).Next
+		if next == nil || *next == "" {
+			return pages, nil
+		}
+
+		cursor, err = nextCursor(*next)
+		if err != nil {
+			return nil, err
+		}
+		if cursor == nil {
+			return pages, nil
+		}
+	}
+
+	return nil, fmt.Errorf("exceeded %d pages", maxPages)
+}
+
+// nextCursor extracts the page cursor from a links.next URL.
+func nextCursor(next string) (*string, error) {
+	nextURL, err := url.Parse(next)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse next page url: %w", err)
+	}
+
+	q := nextURL.Query()
+	for _, key := range []string{"page[cursor]", "page_cursor"} {
+		cursor := q.Get(key)
+		if cursor != "" {
+			return &cursor, nil
+		}
+	}
+
+	return nil, nil
+}
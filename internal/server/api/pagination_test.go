@@ -0,0 +1,102 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/oliverbenns/klaviyo-report/generated/klaviyo"
+	"github.com/oliverbenns/klaviyo-report/internal/conv"
+)
+
+func TestNextCursor(t *testing.T) {
+	tests := []struct {
+		name    string
+		next    string
+		want    *string
+		wantErr bool
+	}{
+		{name: "page cursor", next: "https://a.klaviyo.com/api/campaigns/?page%5Bcursor%5D=abc123", want: conv.Ptr("abc123")},
+		{name: "unescaped page cursor", next: "https://a.klaviyo.com/api/campaigns/?page[cursor]=abc123&filter=x", want: conv.Ptr("abc123")},
+		{name: "page_cursor", next: "https://a.klaviyo.com/api/metric-aggregates/?page_cursor=def456", want: conv.Ptr("def456")},
+		{name: "no cursor", next: "https://a.klaviyo.com/api/campaigns/?filter=x"},
+		{name: "invalid url", next: "://a.klaviyo.com", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := nextCursor(tt.next)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if conv.Val(got) != conv.Val(tt.want) || (got == nil) != (tt.want == nil) {
+				t.Errorf("nextCursor(%q) = %v, want %v", tt.next, conv.Val(got), conv.Val(tt.want))
+			}
+		})
+	}
+}
+
+type testPage struct {
+	Items []int
+	Next  *string
+}
+
+func testPageLinks(page *testPage) klaviyo.CollectionLinks {
+	return klaviyo.CollectionLinks{Next: page.Next}
+}
+
+func TestPaginate(t *testing.T) {
+	pages := map[string]*testPage{
+		"":   {Items: []int{1, 2}, Next: conv.Ptr("https://a.klaviyo.com/api/x/?page%5Bcursor%5D=p2")},
+		"p2": {Items: []int{3}, Next: conv.Ptr("https://a.klaviyo.com/api/x/?page%5Bcursor%5D=p3")},
+		"p3": {Items: []int{4}},
+	}
+
+	got, err := paginate(func(cursor *string) (*testPage, error) {
+		return pages[conv.Val(cursor)], nil
+	}, testPageLinks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items := []int{}
+	for _, page := range got {
+		items = append(items, page.Items...)
+	}
+	if fmt.Sprint(items) != "[1 2 3 4]" {
+		t.Errorf("items = %v, want [1 2 3 4]", items)
+	}
+}
+
+func TestPaginateError(t *testing.T) {
+	fetchErr := errors.New("boom")
+	_, err := paginate(func(cursor *string) (*testPage, error) {
+		if cursor != nil {
+			return nil, fetchErr
+		}
+		return &testPage{Next: conv.Ptr("https://a.klaviyo.com/api/x/?page%5Bcursor%5D=p2")}, nil
+	}, testPageLinks)
+	if !errors.Is(err, fetchErr) {
+		t.Errorf("err = %v, want %v", err, fetchErr)
+	}
+}
+
+func TestPaginateMaxPages(t *testing.T) {
+	fetches := 0
+	_, err := paginate(func(cursor *string) (*testPage, error) {
+		fetches++
+		return &testPage{Next: conv.Ptr("https://a.klaviyo.com/api/x/?page%5Bcursor%5D=same")}, nil
+	}, testPageLinks)
+	if err == nil {
+		t.Error("expected an error for a cursor that never ends")
+	}
+	if fetches != maxPages {
+		t.Errorf("fetches = %d, want %d", fetches, maxPages)
+	}
+}
@@ -0,0 +1,292 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oliverbenns/klaviyo-report/internal/pdf"
+)
+
+type PDFOptions struct {
+	// Logo is an optional PNG or JPEG shown in the header.
+	Logo []byte
+	// Charts adds a revenue per campaign bar chart.
+	Charts bool
+}
+
+const (
+	pdfMargin    = 40.
+	pdfRowHeight = 16.
+	pdfFontSize  = 8.
+	pdfChartBars = 10
+)
+
+var pdfAccent = pdf.Color{R: 0.16, G: 0.38, B: 0.62}
+
+type pdfColumn struct {
+	Title string
+	Width float64
+	Value func(campaign KlaviyoReportTemplateCampaign, ccy CurrencyFormat) string
+}
+
+var pdfCampaignColumns = []pdfColumn{
+	{"Name", 175, func(c KlaviyoReportTemplateCampaign, ccy CurrencyFormat) string {
+		if c.OrderStatus == OrderStatusDataMissing {
+			return "(data missing) " + c.Name
+		}
+		return c.Name
+	}},
+	{"Recipients", 55, func(c KlaviyoReportTemplateCampaign, ccy CurrencyFormat) string {
+		if c.RecipientSource == RecipientSourceEstimate {
+			return fmt.Sprintf("~%d", c.TotalRecipients)
+		}
+		return fmt.Sprint(c.TotalRecipients)
+	}},
+	{"Orders", 40, func(c KlaviyoReportTemplateCampaign, ccy CurrencyFormat) string { return fmt.Sprint(c.OrdersPlaced) }},
+	{"Revenue", 60, func(c KlaviyoReportTemplateCampaign, ccy CurrencyFormat) string { return ccy.Format(c.Revenue) }},
+	{"Conv. Rate", 50, func(c KlaviyoReportTemplateCampaign, ccy CurrencyFormat) string {
+		return formatPercent(c.ConversionRate)
+	}},
+	{"Conv. Value", 65, func(c KlaviyoReportTemplateCampaign, ccy CurrencyFormat) string { return ccy.Format(c.ConversionValue) }},
+	{"Rev. / Recipient", 70, func(c KlaviyoReportTemplateCampaign, ccy CurrencyFormat) string {
+		return ccy.Format(c.RevenuePerRecipient)
+	}},
+}
+
+// RenderKlaviyoReportPDF renders the report headline KPIs and campaign
+// table as a PDF.
+func RenderKlaviyoReportPDF(w io.Writer, data KlaviyoReportTemplateData, opts PDFOptions) error {
+	doc := pdf.New()
+	page := doc.AddPage()
+	y := pdfMargin
+
+	if len(opts.Logo) > 0 {
+		logo, err := doc.LoadImage(bytes.NewReader(opts.Logo))
+		if err != nil {
+			return fmt.Errorf("failed to load logo: %w", err)
+		}
+
+		// Fit within 120x40 keeping the aspect ratio.
+		h := 40.
+		w := h * float64(logo.Width) / float64(logo.Height)
+		if w > 120 {
+			w = 120
+			h = w * float64(logo.Height) / float64(logo.Width)
+		}
+		page.Image(logo, pdf.PageWidth-pdfMargin-w, y, w, h)
+	}
+
+	page.Text(pdfMargin, y+16, 18, true, pdf.Black, fmt.Sprintf("Performance Report: %s", data.AccountName))
+	page.Text(pdfMargin, y+32, 10, false, pdf.Grey, fmt.Sprintf("%s: %s", data.Range.Label, data.Range.String()))
+	y += 60
+
+	ccy := data.CurrencyFormat()
+	y = renderPDFKPIs(page, y, data.KPIs, ccy)
+
+	if opts.Charts && len(data.Campaigns) > 0 {
+		y = renderPDFChart(page, y, data.Campaigns, ccy)
+	}
+
+	page.Text(pdfMargin, y, 12, true, pdf.Black, "Campaigns")
+	y += 10
+	y = renderPDFTableHeader(page, y)
+
+	for _, campaign := range data.Campaigns {
+		if y+pdfRowHeight > pdf.PageHeight-pdfMargin {
+			page = doc.AddPage()
+			y = renderPDFTableHeader(page, pdfMargin)
+		}
+
+		renderPDFTableRow(page, y, campaign, ccy, false)
+		y += pdfRowHeight
+	}
+
+	if y+pdfRowHeight > pdf.PageHeight-pdfMargin {
+		page = doc.AddPage()
+		y = renderPDFTableHeader(page, pdfMargin)
+	}
+	renderPDFTableRow(page, y, KlaviyoReportTemplateCampaign{
+		Name:                "Total",
+		TotalRecipients:     data.Totals.TotalRecipients,
+		OrdersPlaced:        data.Totals.OrdersPlaced,
+		Revenue:             data.Totals.Revenue,
+		ConversionRate:      data.Totals.ConversionRate,
+		ConversionValue:     data.Totals.ConversionValue,
+		RevenuePerRecipient: data.Totals.RevenuePerRecipient,
+		Engagement:          data.Totals.Engagement,
+	}, ccy, true)
+
+	_, err := doc.WriteTo(w)
+	return err
+}
+
+// renderPDFKPIs draws the account level KPIs, matching the HTML overview.
+func renderPDFKPIs(page *pdf.Page, y float64, reportKPIs ReportKPIs, ccy CurrencyFormat) float64 {
+	kpis := []struct {
+		Label string
+		Value string
+	}{
+		{"Attributed Revenue", ccy.Format(reportKPIs.AttributedRevenue)},
+		{"Orders", fmt.Sprint(reportKPIs.OrdersPlaced)},
+		{"Conversion Rate", formatPercent(reportKPIs.ConversionRate)},
+		{"Avg. Order Value", ccy.Format(reportKPIs.AverageOrderValue)},
+		{"Revenue / Recipient", ccy.Format(reportKPIs.RevenuePerRecipient)},
+		{"Store Share", formatPercent(reportKPIs.RevenueShare)},
+	}
+
+	gap := 8.
+	width := (pdf.PageWidth - 2*pdfMargin - gap*float64(len(kpis)-1)) / float64(len(kpis))
+	for i, kpi := range kpis {
+		x := pdfMargin + float64(i)*(width+gap)
+		page.Rect(x, y, width, 48, pdf.LightGrey)
+		page.Text(x+8, y+16, 8, false, pdf.Grey, kpi.Label)
+		page.Text(x+8, y+36, 10, true, pdfAccent, kpi.Value)
+	}
+
+	return y + 72
+}
+
+// renderPDFChart draws a horizontal bar chart of the highest revenue
+// campaigns.
+func renderPDFChart(page *pdf.Page, y float64, campaigns []KlaviyoReportTemplateCampaign, ccy CurrencyFormat) float64 {
+	top := make([]KlaviyoReportTemplateCampaign, len(campaigns))
+	copy(top, campaigns)
+	sort.SliceStable(top, func(i, j int) bool {
+		return top[i].Revenue > top[j].Revenue
+	})
+	if len(top) > pdfChartBars {
+		top = top[:pdfChartBars]
+	}
+
+	page.Text(pdfMargin, y, 12, true, pdf.Black, "Revenue by Campaign")
+	y += 12
+
+	maxRevenue := top[0].Revenue
+	labelWidth := 175.
+	valueWidth := 60.
+	barMaxWidth := pdf.PageWidth - 2*pdfMargin - labelWidth - valueWidth
+	barHeight := 10.
+
+	for _, campaign := range top {
+		page.Text(pdfMargin, y+8, pdfFontSize, false, pdf.Black, truncatePDFText(campaign.Name, labelWidth-6, pdfFontSize, false))
+
+		barWidth := 0.
+		if maxRevenue > 0 {
+			barWidth = barMaxWidth * campaign.Revenue / maxRevenue
+		}
+		page.Rect(pdfMargin+labelWidth, y, barWidth, barHeight, pdfAccent)
+		page.Text(pdfMargin+labelWidth+barWidth+4, y+8, pdfFontSize, false, pdf.Grey, ccy.Format(campaign.Revenue))
+
+		y += barHeight + 4
+	}
+
+	return y + 20
+}
+
+func renderPDFTableHeader(page *pdf.Page, y float64) float64 {
+	x := pdfMargin
+	for i, column := range pdfCampaignColumns {
+		if i == 0 {
+			page.Text(x, y+10, pdfFontSize, true, pdf.Black, column.Title)
+		} else {
+			page.TextRight(x+column.Width, y+10, pdfFontSize, true, pdf.Black, column.Title)
+		}
+		x += column.Width
+	}
+
+	y += pdfRowHeight
+	page.Line(pdfMargin, y-3, pdf.PageWidth-pdfMargin, y-3, 0.75, pdf.Black)
+	return y
+}
+
+func renderPDFTableRow(page *pdf.Page, y float64, campaign KlaviyoReportTemplateCampaign, ccy CurrencyFormat, bold bool) {
+	if bold {
+		page.Line(pdfMargin, y-3, pdf.PageWidth-pdfMargin, y-3, 0.75, pdf.Black)
+	}
+
+	x := pdfMargin
+	for i, column := range pdfCampaignColumns {
+		value := column.Value(campaign, ccy)
+		if i == 0 {
+			page.Text(x, y+8, pdfFontSize, bold, pdf.Black, truncatePDFText(value, column.Width-6, pdfFontSize, bold))
+		} else {
+			page.TextRight(x+column.Width, y+8, pdfFontSize, bold, pdf.Black, value)
+		}
+		x += column.Width
+	}
+
+	if !bold {
+		page.Line(pdfMargin, y+pdfRowHeight-3, pdf.PageWidth-pdfMargin, y+pdfRowHeight-3, 0.25, pdf.LightGrey)
+	}
+}
+
+func truncatePDFText(text string, width float64, size float64, bold bool) string {
+	if pdf.TextWidth(text, size, bold) <= width {
+		return text
+	}
+
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.TextWidth(string(runes)+"...", size, bold) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+// loadLogo reads the logo from a path or URL. Failures are logged and the
+// report is rendered without a logo, including logos that aren't a PNG or
+// JPEG, e.g. an SVG, an HTML error page or a truncated download.
+func (s *Service) loadLogo(ctx context.Context, logo string) []byte {
+	if logo == "" {
+		return nil
+	}
+
+	b, err := readLogo(ctx, logo)
+	if err != nil {
+		s.Logger.Warn("failed to load logo", "logo", logo, "error", err)
+		return nil
+	}
+
+	// Decoders are registered by the pdf package.
+	_, _, err = image.Decode(bytes.NewReader(b))
+	if err != nil {
+		s.Logger.Warn("failed to decode logo", "logo", logo, "error", err)
+		return nil
+	}
+
+	return b
+}
+
+func readLogo(ctx context.Context, logo string) ([]byte, error) {
+	if !strings.HasPrefix(logo, "http://") && !strings.HasPrefix(logo, "https://") {
+		return os.ReadFile(logo)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", logo, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != 200 {
+		return nil, fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+
+	// Logos are small, guard against huge responses.
+	return io.ReadAll(io.LimitReader(res.Body, 5<<20))
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testPNG(t *testing.T) []byte {
+	buf := bytes.Buffer{}
+	err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestLoadLogo(t *testing.T) {
+	s := &Service{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	logo := testPNG(t)
+
+	tests := []struct {
+		name    string
+		content []byte
+		want    []byte
+	}{
+		{name: "png", content: logo, want: logo},
+		{name: "svg", content: []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`)},
+		{name: "html", content: []byte(`<!doctype html><title>Not Found</title>`)},
+		{name: "truncated", content: logo[:len(logo)/2]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "logo")
+			err := os.WriteFile(path, tt.content, 0o600)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := s.loadLogo(context.Background(), path)
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("loadLogo() returned %d bytes, want %d", len(got), len(tt.want))
+			}
+		})
+	}
+
+	if got := s.loadLogo(context.Background(), filepath.Join(t.TempDir(), "missing.png")); got != nil {
+		t.Error("expected no logo for a missing file")
+	}
+}
+
+func TestRenderKlaviyoReportPDFLogo(t *testing.T) {
+	err := RenderKlaviyoReportPDF(io.Discard, KlaviyoReportTemplateData{}, PDFOptions{Logo: testPNG(t)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The CLI passes the logo as is, so an invalid one is an error.
+	err = RenderKlaviyoReportPDF(io.Discard, KlaviyoReportTemplateData{}, PDFOptions{Logo: []byte("<svg/>")})
+	if err == nil {
+		t.Error("expected an error for an invalid logo")
+	}
+}
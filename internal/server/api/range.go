@@ -0,0 +1,188 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const dateLayout = "2006-01-02"
+
+// reportNowGranularity rounds down "now" so that ranges ending now produce
+// stable Klaviyo queries (and cache keys) for a short while.
+const reportNowGranularity = 15 * time.Minute
+
+func reportNow() time.Time {
+	return time.Now().UTC().Truncate(reportNowGranularity)
+}
+
+// reportLocation returns the location of a Klaviyo account's IANA timezone so
+// day and month boundaries match what the account sees in Klaviyo.
+func reportLocation(timezone string) (*time.Location, error) {
+	if timezone == "" {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+	}
+
+	return loc, nil
+}
+
+type ReportRange struct {
+	Key   string    `json:"key"`
+	Label string    `json:"label"`
+	From  time.Time `json:"from"`
+	To    time.Time `json:"to"`
+}
+
+func (r ReportRange) String() string {
+	return fmt.Sprintf("%s – %s", r.From.Format(dateLayout), r.LastDay().Format(dateLayout))
+}
+
+// LastDay returns the last day included in the range as To is exclusive.
+func (r ReportRange) LastDay() time.Time {
+	return r.To.Add(-time.Nanosecond)
+}
+
+// Filter returns the Klaviyo filter for the range against the given field.
+func (r ReportRange) Filter(field string) string {
+	return fmt.Sprintf("greater-or-equal(%s,%s),less-than(%s,%s)", field, r.From.UTC().Format(time.RFC3339), field, r.To.UTC().Format(time.RFC3339))
+}
+
+// Timezone returns the IANA timezone the range boundaries are in.
+func (r ReportRange) Timezone() string {
+	return r.From.Location().String()
+}
+
+type ReportRangeOption struct {
+	Key   string
+	Label string
+}
+
+var reportRangeOptions = []ReportRangeOption{
+	{Key: "last_7_days", Label: "Last 7 days"},
+	{Key: "last_30_days", Label: "Last 30 days"},
+	{Key: "last_90_days", Label: "Last 90 days"},
+	{Key: "month_to_date", Label: "Month to date"},
+	{Key: "last_month", Label: "Last month"},
+	{Key: "quarter_to_date", Label: "Quarter to date"},
+	{Key: "last_quarter", Label: "Last quarter"},
+	{Key: "year_to_date", Label: "Year to date"},
+}
+
+const defaultReportRangeKey = "last_30_days"
+
+// parseReportRange reads the reporting window from the query string.
+// Supported parameters, in order of precedence:
+//   - from & to: custom dates (YYYY-MM-DD), to is inclusive
+//   - month: a specific month (YYYY-MM)
+//   - range: one of reportRangeOptions
+func parseReportRange(c *gin.Context, now time.Time) (ReportRange, error) {
+	from := c.Query("from")
+	to := c.Query("to")
+	if from != "" || to != "" {
+		return customReportRange(from, to, now)
+	}
+
+	month := c.Query("month")
+	if month != "" {
+		start, err := time.ParseInLocation("2006-01", month, now.Location())
+		if err != nil {
+			return ReportRange{}, fmt.Errorf("invalid month %q: %w", month, err)
+		}
+
+		return ReportRange{
+			Key:   "month",
+			Label: start.Format("January 2006"),
+			From:  start,
+			To:    start.AddDate(0, 1, 0),
+		}, nil
+	}
+
+	key := c.DefaultQuery("range", defaultReportRangeKey)
+	return presetReportRange(key, now)
+}
+
+func customReportRange(from, to string, now time.Time) (ReportRange, error) {
+	if from == "" || to == "" {
+		return ReportRange{}, fmt.Errorf("from and to must both be set")
+	}
+
+	start, err := time.ParseInLocation(dateLayout, from, now.Location())
+	if err != nil {
+		return ReportRange{}, fmt.Errorf("invalid from date %q: %w", from, err)
+	}
+
+	end, err := time.ParseInLocation(dateLayout, to, now.Location())
+	if err != nil {
+		return ReportRange{}, fmt.Errorf("invalid to date %q: %w", to, err)
+	}
+
+	if end.Before(start) {
+		return ReportRange{}, fmt.Errorf("to date must not be before from date")
+	}
+
+	return ReportRange{
+		Key:   "custom",
+		Label: "Custom",
+		From:  start,
+		To:    end.AddDate(0, 0, 1),
+	}, nil
+}
+
+func presetReportRange(key string, now time.Time) (ReportRange, error) {
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	quarterStart := time.Date(now.Year(), now.Month()-(now.Month()-1)%3, 1, 0, 0, 0, 0, now.Location())
+
+	rng := ReportRange{Key: key}
+	switch key {
+	case "last_7_days":
+		rng.From, rng.To = now.AddDate(0, 0, -7), now
+	case "last_30_days":
+		rng.From, rng.To = now.AddDate(0, 0, -30), now
+	case "last_90_days":
+		rng.From, rng.To = now.AddDate(0, 0, -90), now
+	case "month_to_date":
+		rng.From, rng.To = monthStart, now
+	case "last_month":
+		rng.From, rng.To = monthStart.AddDate(0, -1, 0), monthStart
+	case "quarter_to_date":
+		rng.From, rng.To = quarterStart, now
+	case "last_quarter":
+		rng.From, rng.To = quarterStart.AddDate(0, -3, 0), quarterStart
+	case "year_to_date":
+		rng.From, rng.To = time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location()), now
+	default:
+		return ReportRange{}, fmt.Errorf("unknown range %q", key)
+	}
+
+	for _, option := range reportRangeOptions {
+		if option.Key == key {
+			rng.Label = option.Label
+		}
+	}
+
+	return rng, nil
+}
+
+// Previous returns the equivalent period immediately before the range.
+// Calendar based ranges step back by whole months so that e.g. last month
+// is compared against the month before it.
+func (r ReportRange) Previous() ReportRange {
+	prev := ReportRange{Key: r.Key, Label: "Previous period", To: r.From}
+
+	switch r.Key {
+	case "month", "last_month":
+		prev.From = r.From.AddDate(0, -1, 0)
+	case "last_quarter":
+		prev.From = r.From.AddDate(0, -3, 0)
+	default:
+		prev.From = r.From.Add(-r.To.Sub(r.From))
+	}
+
+	return prev
+}
@@ -0,0 +1,244 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	// Tests load account timezones without relying on the system database.
+	_ "time/tzdata"
+
+	"github.com/gin-gonic/gin"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestPresetReportRange(t *testing.T) {
+	now := time.Date(2024, 5, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		key      string
+		wantFrom time.Time
+		wantTo   time.Time
+	}{
+		{"last_7_days", now.AddDate(0, 0, -7), now},
+		{"last_30_days", now.AddDate(0, 0, -30), now},
+		{"last_90_days", now.AddDate(0, 0, -90), now},
+		{"month_to_date", date(2024, 5, 1), now},
+		{"last_month", date(2024, 4, 1), date(2024, 5, 1)},
+		{"quarter_to_date", date(2024, 4, 1), now},
+		{"last_quarter", date(2024, 1, 1), date(2024, 4, 1)},
+		{"year_to_date", date(2024, 1, 1), now},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			rng, err := presetReportRange(tt.key, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !rng.From.Equal(tt.wantFrom) || !rng.To.Equal(tt.wantTo) {
+				t.Errorf("got %s – %s, want %s – %s", rng.From, rng.To, tt.wantFrom, tt.wantTo)
+			}
+			if rng.Label == "" {
+				t.Error("missing label")
+			}
+		})
+	}
+
+	_, err := presetReportRange("last_year", now)
+	if err == nil {
+		t.Error("expected an error for an unknown range")
+	}
+}
+
+func TestPresetReportRangeQuarterBoundaries(t *testing.T) {
+	tests := []struct {
+		now      time.Time
+		wantFrom time.Time
+	}{
+		{date(2024, 1, 1), date(2024, 1, 1)},
+		{date(2024, 3, 31), date(2024, 1, 1)},
+		{date(2024, 7, 1), date(2024, 7, 1)},
+		{date(2024, 12, 31), date(2024, 10, 1)},
+	}
+
+	for _, tt := range tests {
+		rng, err := presetReportRange("quarter_to_date", tt.now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !rng.From.Equal(tt.wantFrom) {
+			t.Errorf("quarter_to_date at %s starts %s, want %s", tt.now, rng.From, tt.wantFrom)
+		}
+	}
+}
+
+func TestCustomReportRange(t *testing.T) {
+	now := date(2024, 5, 15)
+
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		wantFrom time.Time
+		wantTo   time.Time
+		wantErr  bool
+	}{
+		{name: "to is inclusive", from: "2024-01-01", to: "2024-01-31", wantFrom: date(2024, 1, 1), wantTo: date(2024, 2, 1)},
+		{name: "single day", from: "2024-01-01", to: "2024-01-01", wantFrom: date(2024, 1, 1), wantTo: date(2024, 1, 2)},
+		{name: "missing to", from: "2024-01-01", wantErr: true},
+		{name: "missing from", to: "2024-01-01", wantErr: true},
+		{name: "invalid from", from: "01/01/2024", to: "2024-01-31", wantErr: true},
+		{name: "invalid to", from: "2024-01-01", to: "2024-02-30", wantErr: true},
+		{name: "to before from", from: "2024-01-31", to: "2024-01-01", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rng, err := customReportRange(tt.from, tt.to, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %s", rng)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !rng.From.Equal(tt.wantFrom) || !rng.To.Equal(tt.wantTo) {
+				t.Errorf("got %s – %s, want %s – %s", rng.From, rng.To, tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
+
+func TestParseReportRange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Date(2024, 5, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		query     string
+		wantKey   string
+		wantLabel string
+		wantFrom  time.Time
+		wantTo    time.Time
+		wantErr   bool
+	}{
+		{name: "default", query: "", wantKey: "last_30_days", wantLabel: "Last 30 days", wantFrom: now.AddDate(0, 0, -30), wantTo: now},
+		{name: "preset", query: "range=last_month", wantKey: "last_month", wantLabel: "Last month", wantFrom: date(2024, 4, 1), wantTo: date(2024, 5, 1)},
+		{name: "month", query: "month=2024-02", wantKey: "month", wantLabel: "February 2024", wantFrom: date(2024, 2, 1), wantTo: date(2024, 3, 1)},
+		{name: "custom wins over month", query: "month=2024-02&from=2024-03-01&to=2024-03-10", wantKey: "custom", wantLabel: "Custom", wantFrom: date(2024, 3, 1), wantTo: date(2024, 3, 11)},
+		{name: "month wins over range", query: "range=last_month&month=2024-02", wantKey: "month", wantLabel: "February 2024", wantFrom: date(2024, 2, 1), wantTo: date(2024, 3, 1)},
+		{name: "invalid month", query: "month=2024-13", wantErr: true},
+		{name: "unknown range", query: "range=forever", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/?"+tt.query, nil)
+
+			rng, err := parseReportRange(c, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %s", rng)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rng.Key != tt.wantKey || rng.Label != tt.wantLabel {
+				t.Errorf("got %s %q, want %s %q", rng.Key, rng.Label, tt.wantKey, tt.wantLabel)
+			}
+			if !rng.From.Equal(tt.wantFrom) || !rng.To.Equal(tt.wantTo) {
+				t.Errorf("got %s – %s, want %s – %s", rng.From, rng.To, tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
+
+func TestReportRangeInLocation(t *testing.T) {
+	loc, err := reportLocation("Europe/Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rng, err := presetReportRange("last_month", time.Date(2024, 5, 15, 10, 30, 0, 0, loc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rng.Timezone() != "Europe/Paris" {
+		t.Errorf("Timezone() = %s, want Europe/Paris", rng.Timezone())
+	}
+
+	// Midnight in Paris is 22:00 UTC the day before during summer time.
+	want := "greater-or-equal(datetime,2024-03-31T22:00:00Z),less-than(datetime,2024-04-30T22:00:00Z)"
+	if got := rng.Filter("datetime"); got != want {
+		t.Errorf("Filter() = %s, want %s", got, want)
+	}
+
+	if rng.String() != "2024-04-01 – 2024-04-30" {
+		t.Errorf("String() = %s", rng.String())
+	}
+}
+
+func TestReportLocation(t *testing.T) {
+	loc, err := reportLocation("")
+	if err != nil || loc != time.UTC {
+		t.Errorf("empty timezone = %v, %v, want UTC", loc, err)
+	}
+
+	_, err = reportLocation("Mars/Olympus_Mons")
+	if err == nil {
+		t.Error("expected an error for an unknown timezone")
+	}
+}
+
+func TestReportRangePrevious(t *testing.T) {
+	tests := []struct {
+		name     string
+		rng      ReportRange
+		wantFrom time.Time
+		wantTo   time.Time
+	}{
+		{
+			name:     "last month steps back a calendar month",
+			rng:      ReportRange{Key: "last_month", From: date(2024, 3, 1), To: date(2024, 4, 1)},
+			wantFrom: date(2024, 2, 1),
+			wantTo:   date(2024, 3, 1),
+		},
+		{
+			name:     "month steps back a calendar month",
+			rng:      ReportRange{Key: "month", From: date(2024, 5, 1), To: date(2024, 6, 1)},
+			wantFrom: date(2024, 4, 1),
+			wantTo:   date(2024, 5, 1),
+		},
+		{
+			name:     "last quarter steps back a quarter",
+			rng:      ReportRange{Key: "last_quarter", From: date(2024, 1, 1), To: date(2024, 4, 1)},
+			wantFrom: date(2023, 10, 1),
+			wantTo:   date(2024, 1, 1),
+		},
+		{
+			name:     "other ranges step back their length",
+			rng:      ReportRange{Key: "custom", From: date(2024, 1, 11), To: date(2024, 1, 21)},
+			wantFrom: date(2024, 1, 1),
+			wantTo:   date(2024, 1, 11),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := tt.rng.Previous()
+			if !prev.From.Equal(tt.wantFrom) || !prev.To.Equal(tt.wantTo) {
+				t.Errorf("got %s – %s, want %s – %s", prev.From, prev.To, tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
@@ -2,61 +2,161 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"html/template"
+	"sort"
+	"strings"
 	"time"
 
 	"embed"
 
 	"github.com/gin-gonic/gin"
 	"github.com/oliverbenns/klaviyo-report/generated/klaviyo"
+	"github.com/oliverbenns/klaviyo-report/internal/conv"
+	"github.com/oliverbenns/klaviyo-report/internal/klaviyoclient"
 )
 
 //go:embed report.html
 var reportContent embed.FS
 
 type KlaviyoReportTemplateCampaign struct {
-	Name                string
-	TotalRecipients     int
-	OrdersPlaced        int
-	ConversionRate      float64
-	ConversionValue     float64
-	RevenuePerRecipient float64
+	Name                string            `json:"name"`
+	Channel             string            `json:"channel"`
+	Tags                []string          `json:"tags"`
+	SendTime            time.Time         `json:"send_time"`
+	Subject             string            `json:"subject"`
+	PreviewText         string            `json:"preview_text"`
+	Audiences           CampaignAudiences `json:"audiences"`
+	TotalRecipients     int               `json:"total_recipients"`
+	RecipientSource     string            `json:"recipient_source"`
+	OrderStatus         string            `json:"order_status"`
+	OrdersPlaced        int               `json:"orders_placed"`
+	Revenue             float64           `json:"revenue"`
+	ConversionRate      float64           `json:"conversion_rate"`
+	ConversionValue     float64           `json:"conversion_value"`
+	RevenuePerRecipient float64           `json:"revenue_per_recipient"`
+	Engagement
+	// VariationTest is set for campaigns sent with A/B test variations.
+	VariationTest *VariationTest `json:"variation_test,omitempty"`
+	// SMS is set for SMS campaigns.
+	SMS *SMSEngagement `json:"sms,omitempty"`
+}
+
+// Where a campaign's TotalRecipients comes from.
+const (
+	// RecipientSourceReceived is the number of Received Email events.
+	RecipientSourceReceived = "received"
+	// RecipientSourceEstimate is Klaviyo's recipient estimation, used when
+	// there are no Received Email or Sent SMS events.
+	RecipientSourceEstimate = "estimate"
+	// RecipientSourceSent is the number of Sent SMS events.
+	RecipientSourceSent = "sent"
+)
+
+// A campaign's OrderStatus.
+const (
+	OrderStatusAttributed = "attributed"
+	// OrderStatusNoOrders is a campaign that was delivered but has no
+	// attributed orders.
+	OrderStatusNoOrders = "no_orders"
+	// OrderStatusDataMissing is a campaign with no orders and no delivery
+	// events either, e.g. Klaviyo has not processed the send yet.
+	OrderStatusDataMissing = "data_missing"
+)
+
+func campaignOrderStatus(metric Metric, recipients campaignRecipients) string {
+	switch {
+	case metric.Count > 0:
+		return OrderStatusAttributed
+	case recipients.Source == RecipientSourceEstimate:
+		return OrderStatusDataMissing
+	default:
+		return OrderStatusNoOrders
+	}
+}
+
+type campaignRecipients struct {
+	Count  int
+	Source string
+}
+
+// reportCampaign is the subset of a Klaviyo campaign the report uses.
+type reportCampaign struct {
+	ID                string
+	Name              string
+	Channel           string
+	Tags              []string
+	SendTime          time.Time
+	IncludedAudiences []string
+	ExcludedAudiences []string
+}
+
+type KlaviyoReportTotals struct {
+	TotalRecipients     int     `json:"total_recipients"`
+	OrdersPlaced        int     `json:"orders_placed"`
+	Revenue             float64 `json:"revenue"`
+	ConversionRate      float64 `json:"conversion_rate"`
+	ConversionValue     float64 `json:"conversion_value"`
+	RevenuePerRecipient float64 `json:"revenue_per_recipient"`
+	Engagement
 }
 
 type KlaviyoReportTemplateData struct {
-	AccountName string
-	Campaigns   []KlaviyoReportTemplateCampaign
+	AccountID   string `json:"account_id"`
+	AccountName string `json:"account_name"`
+	ApiKey      string `json:"-"`
+	Logo        string `json:"-"`
+	Currency    string `json:"currency"`
+	// Metric is the conversion metric orders and revenue are counted from.
+	Metric        KlaviyoMetric       `json:"conversion_metric"`
+	MetricOptions []KlaviyoMetric     `json:"-"`
+	Channel       string              `json:"channel"`
+	Range         ReportRange         `json:"range"`
+	RangeOptions  []ReportRangeOption `json:"-"`
+	// Totals are of the campaigns matching CampaignFilters.
+	Totals          KlaviyoReportTotals             `json:"totals"`
+	CampaignFilters CampaignFilters                 `json:"campaign_filters"`
+	Campaigns       []KlaviyoReportTemplateCampaign `json:"campaigns"`
+	Flows           []KlaviyoReportTemplateFlow     `json:"flows"`
+	KPIs            ReportKPIs                      `json:"kpis"`
+	Comparison      ReportComparison                `json:"comparison"`
+	TimeSeries      *ReportTimeSeries               `json:"time_series,omitempty"`
 }
 
 func (s *Service) GetKlaviyoReport(c *gin.Context) {
-	klaviyoAccountID := c.Param("klaviyo_account_id")
-	if klaviyoAccountID == "" {
-		s.Logger.Warn("klaviyo_account_id is required")
-		c.Status(400)
+	format := c.DefaultQuery("format", "html")
+	if format != "html" && !isExportFormat(format) {
+		s.renderError(c, "invalid report format", badRequestError{fmt.Errorf("unsupported format %q", format)})
 		return
 	}
 
-	res, err := s.KlaviyoClient.GetAccountWithResponse(c.Request.Context(), klaviyoAccountID, &klaviyo.GetAccountParams{
-		Revision: "2023-12-15",
-	})
+	data, err := s.buildKlaviyoReport(c)
 	if err != nil {
-		s.Logger.Error("failed to get account", "error", err)
-		c.Status(500)
+		s.renderError(c, "failed to build report", err)
 		return
 	}
 
-	campaigns, err := s.getKlaviyoReportCampaigns(c.Request.Context())
-	if err != nil {
-		s.Logger.Error("failed to get campaigns", "error", err)
-		c.Status(500)
+	if isExportFormat(format) {
+		s.exportKlaviyoReport(c, format, data)
 		return
 	}
 
 	// Create a template with the custom function
 	funcMap := template.FuncMap{
 		"formatPercent": formatPercent,
-		"formatCcy":     formatCcy,
+		"formatCcy":     data.CurrencyFormat().Format,
+		"formatChange":  formatChange,
+		"chart":         timeSeriesChart,
+		"sortURL": func(key string) string {
+			return campaignSortURL(c.Request.URL.Query(), data.CampaignFilters.Sort, key)
+		},
+		"sortIndicator": func(key string) string {
+			return campaignSortIndicator(data.CampaignFilters.Sort, key)
+		},
+		"hiddenQuery": func(exclude ...string) template.HTML {
+			return hiddenQueryInputs(c.Request.URL.Query(), exclude...)
+		},
 	}
 
 	tmpl, err := template.New("report.html").Funcs(funcMap).ParseFS(reportContent, "report.html")
@@ -66,10 +166,7 @@ func (s *Service) GetKlaviyoReport(c *gin.Context) {
 		return
 	}
 
-	err = tmpl.Execute(c.Writer, KlaviyoReportTemplateData{
-		AccountName: res.JSON200.Data.Attributes.ContactInformation.OrganizationName,
-		Campaigns:   campaigns,
-	})
+	err = tmpl.Execute(c.Writer, data)
 	if err != nil {
 		s.Logger.Error("failed to execute template", "error", err)
 		c.Status(500)
@@ -80,67 +177,406 @@ func (s *Service) GetKlaviyoReport(c *gin.Context) {
 	return
 }
 
-func formatCcy(value float64) string {
-	return fmt.Sprintf("€%.2f", value)
+// GetKlaviyoReportJSON returns the same report as GetKlaviyoReport as JSON.
+// See the README for the schema.
+func (s *Service) GetKlaviyoReportJSON(c *gin.Context) {
+	data, err := s.buildKlaviyoReport(c)
+	if err != nil {
+		s.renderJSONError(c, "failed to build report", err)
+		return
+	}
+
+	c.PureJSON(200, data)
 }
 
-func formatPercent(value float64) string {
-	return fmt.Sprintf("%.4f%%", value*100)
+func (d KlaviyoReportTemplateData) CurrencyFormat() CurrencyFormat {
+	return currencyFormat(d.Currency)
 }
 
-func (s *Service) getKlaviyoReportCampaigns(ctx context.Context) ([]KlaviyoReportTemplateCampaign, error) {
-	now := time.Now().UTC()
+func (s *Service) buildKlaviyoReport(c *gin.Context) (KlaviyoReportTemplateData, error) {
+	klaviyoAccountID := c.Param("klaviyo_account_id")
+	if klaviyoAccountID == "" {
+		return KlaviyoReportTemplateData{}, badRequestError{errors.New("klaviyo_account_id is required")}
+	}
+
+	currencyOverride := strings.ToUpper(c.Query("currency"))
+	if currencyOverride != "" && !isCurrencyCode(currencyOverride) {
+		return KlaviyoReportTemplateData{}, badRequestError{fmt.Errorf("invalid currency %q", currencyOverride)}
+	}
+
+	interval, err := parseTimeSeriesInterval(c)
+	if err != nil {
+		return KlaviyoReportTemplateData{}, badRequestError{err}
+	}
+
+	channel, err := parseReportChannel(c)
+	if err != nil {
+		return KlaviyoReportTemplateData{}, badRequestError{err}
+	}
+
+	campaignFilters, err := parseCampaignFilters(c)
+	if err != nil {
+		return KlaviyoReportTemplateData{}, badRequestError{err}
+	}
+
+	account, err := s.Accounts.Get(c.Request.Context(), klaviyoAccountID)
+	if err != nil {
+		return KlaviyoReportTemplateData{}, fmt.Errorf("failed to get account %s: %w", klaviyoAccountID, err)
+	}
+
+	// The report's metric (ID or name) overrides the account's for this request.
+	metric := c.Query("metric_id")
+	if metric == "" {
+		metric = c.Query("metric")
+	}
+	if metric != "" {
+		account.ConversionMetric = metric
+	}
 
-	res, err := s.KlaviyoClient.GetCampaignsWithResponse(ctx, &klaviyo.GetCampaignsParams{
+	ctx, err := s.withKlaviyoAccount(c, account)
+	if err != nil {
+		return KlaviyoReportTemplateData{}, err
+	}
+
+	accountParams := &klaviyo.GetAccountParams{
 		Revision: "2023-12-15",
-		Filter:   fmt.Sprintf("equals(messages.channel,'email'),equals(archived,false),less-than(scheduled_at,%s)", now.Format(time.RFC3339)),
+	}
+	res, err := cacheResponse(ctx, s, "account", []any{klaviyoAccountID, accountParams}, func() (*klaviyo.GetAccountRes, error) {
+		res, err := s.klaviyo(ctx).GetAccountWithResponse(ctx, klaviyoAccountID, accountParams)
+		if err != nil {
+			return nil, err
+		}
+		return klaviyoclient.CheckResponse(res.StatusCode(), res.Body, res.JSON200)
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get campaigns: %w", err)
+		return KlaviyoReportTemplateData{}, fmt.Errorf("failed to get klaviyo account: %w", err)
 	}
 
-	metrics, err := s.getMetrics(ctx, "$attributed_message")
+	loc, err := reportLocation(res.Data.Attributes.Timezone)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get metrics conversions: %w", err)
+		s.Logger.Warn("failed to load account timezone, using UTC", "account_id", klaviyoAccountID, "error", err)
+		loc = time.UTC
 	}
 
-	templateCampaigns := []KlaviyoReportTemplateCampaign{}
-	for _, campaign := range res.JSON200.Data {
-		recipientRes, err := s.KlaviyoClient.GetCampaignRecipientEstimationWithResponse(ctx, campaign.Id, &klaviyo.GetCampaignRecipientEstimationParams{
-			Revision: "2023-12-15",
+	rng, err := parseReportRange(c, reportNow().In(loc))
+	if err != nil {
+		return KlaviyoReportTemplateData{}, badRequestError{err}
+	}
+
+	conversionMetric, err := s.getConversionMetric(ctx)
+	if err != nil {
+		return KlaviyoReportTemplateData{}, err
+	}
+
+	metricOptions, err := s.listMetrics(ctx, nil)
+	if err != nil {
+		return KlaviyoReportTemplateData{}, err
+	}
+	sort.SliceStable(metricOptions, func(i, j int) bool {
+		return metricOptions[i].String() < metricOptions[j].String()
+	})
+
+	metrics, err := s.getMetrics(ctx, "$attributed_message", rng)
+	if err != nil {
+		return KlaviyoReportTemplateData{}, fmt.Errorf("failed to get metrics: %w", err)
+	}
+
+	previousRange := rng.Previous()
+	previousMetrics, err := s.getMetrics(ctx, "$attributed_message", previousRange)
+	if err != nil {
+		return KlaviyoReportTemplateData{}, fmt.Errorf("failed to get previous period metrics: %w", err)
+	}
+
+	// Recipients of every message, whatever the channel, to match the
+	// attributed metrics.
+	messageRecipients, err := s.getRecipients(ctx, "$message", rng)
+	if err != nil {
+		return KlaviyoReportTemplateData{}, fmt.Errorf("failed to get message recipients: %w", err)
+	}
+
+	campaigns, err := s.getKlaviyoReportCampaigns(ctx, rng, metrics, channel)
+	if err != nil {
+		return KlaviyoReportTemplateData{}, fmt.Errorf("failed to get campaigns: %w", err)
+	}
+
+	flows, err := s.getKlaviyoReportFlows(ctx, rng, metrics, messageRecipients)
+	if err != nil {
+		return KlaviyoReportTemplateData{}, fmt.Errorf("failed to get flows: %w", err)
+	}
+
+	filteredCampaigns := campaignFilters.Apply(campaigns)
+
+	storeTotal, err := s.getStoreTotal(ctx, rng)
+	if err != nil {
+		return KlaviyoReportTemplateData{}, fmt.Errorf("failed to get store total: %w", err)
+	}
+
+	var timeSeries *ReportTimeSeries
+	if interval != "" {
+		timeSeries, err = s.getTimeSeries(ctx, interval, rng)
+		if err != nil {
+			return KlaviyoReportTemplateData{}, fmt.Errorf("failed to get time series: %w", err)
+		}
+	}
+
+	currency := res.Data.Attributes.PreferredCurrency
+	if currencyOverride != "" {
+		currency = currencyOverride
+	}
+
+	return KlaviyoReportTemplateData{
+		AccountID:       klaviyoAccountID,
+		AccountName:     res.Data.Attributes.ContactInformation.OrganizationName,
+		ApiKey:          s.ApiKey,
+		Logo:            account.Logo,
+		Currency:        currency,
+		Metric:          conversionMetric,
+		MetricOptions:   metricOptions,
+		Channel:         channel,
+		Range:           rng,
+		RangeOptions:    reportRangeOptions,
+		Totals:          calculateTotals(filteredCampaigns),
+		Campaigns:       filteredCampaigns,
+		CampaignFilters: campaignFilters,
+		Flows:           flows,
+		KPIs:            calculateKPIs(metrics.AttributedTotal(), storeTotal, messageRecipients.Total()),
+		Comparison:      calculateComparison(previousRange, metrics.AttributedTotal(), previousMetrics.AttributedTotal()),
+		TimeSeries:      timeSeries,
+	}, nil
+}
+
+func formatPercent(value float64) string {
+	return fmt.Sprintf("%.4f%%", value*100)
+}
+
+func formatChange(value float64) string {
+	return fmt.Sprintf("%+.2f%%", value*100)
+}
+
+func (s *Service) getKlaviyoReportCampaigns(ctx context.Context, rng ReportRange, metrics MetricsByCampaignID, channel string) ([]KlaviyoReportTemplateCampaign, error) {
+	// Only include campaigns that have already been sent.
+	campaignRange := rng
+	now := reportNow()
+	if campaignRange.To.After(now) {
+		campaignRange.To = now
+	}
+
+	campaigns := []reportCampaign{}
+	for _, messageChannel := range campaignChannels(channel) {
+		pages, err := paginate(func(cursor *string) (*klaviyo.GetCampaignResponseCollectionCompoundDocument, error) {
+			params := &klaviyo.GetCampaignsParams{
+				Revision:   "2023-12-15",
+				Filter:     fmt.Sprintf("equals(messages.channel,'%s'),equals(archived,false),%s", messageChannel, campaignRange.Filter("scheduled_at")),
+				Include:    &[]klaviyo.GetCampaignsParamsInclude{"tags"},
+				PageCursor: cursor,
+			}
+			return cacheResponse(ctx, s, "campaigns", params, func() (*klaviyo.GetCampaignResponseCollectionCompoundDocument, error) {
+				res, err := s.klaviyo(ctx).GetCampaignsWithResponse(ctx, params)
+				if err != nil {
+					return nil, err
+				}
+				return klaviyoclient.CheckResponse(res.StatusCode(), res.Body, res.JSON200)
+			})
+		}, func(page *klaviyo.GetCampaignResponseCollectionCompoundDocument) klaviyo.CollectionLinks {
+			return page.Links
 		})
 		if err != nil {
-			return nil, fmt.Errorf("failed to get campaign recipient estimation: %w", err)
+			return nil, fmt.Errorf("failed to get %s campaigns: %w", messageChannel, err)
 		}
 
-		recipientCount := recipientRes.JSON200.Data.Attributes.EstimatedRecipientCount
+		for _, page := range pages {
+			tagNames, err := includedTagNames(page)
+			if err != nil {
+				return nil, err
+			}
 
-		metric, ok := metrics[campaign.Id]
-		if !ok {
-			s.Logger.Warn("failed to get metrics for campaign", "campaign_id", campaign.Id)
-			continue
+			for _, campaign := range page.Data {
+				tags := []string{}
+				if campaign.Relationships != nil && campaign.Relationships.Tags != nil {
+					for _, tag := range campaign.Relationships.Tags.Data {
+						tags = append(tags, tagNames[tag.Id])
+					}
+				}
+
+				sendTime := campaign.Attributes.SendTime
+				if sendTime.IsZero() {
+					sendTime = campaign.Attributes.ScheduledAt
+				}
+
+				campaigns = append(campaigns, reportCampaign{
+					ID:                campaign.Id,
+					Name:              campaign.Attributes.Name,
+					Channel:           messageChannel,
+					Tags:              tags,
+					SendTime:          sendTime.In(rng.From.Location()),
+					IncludedAudiences: conv.Val(campaign.Attributes.Audiences.Included),
+					ExcludedAudiences: conv.Val(campaign.Attributes.Audiences.Excluded),
+				})
+			}
 		}
+	}
 
-		templateCampaign := calculateCampaign(campaign.Attributes.Name, metric, recipientCount)
+	engagement := EngagementByID{}
+	smsEngagement := SMSEngagementByID{}
+	for _, messageChannel := range campaignChannels(channel) {
+		var err error
+		switch messageChannel {
+		case ChannelEmail:
+			engagement, err = s.getEngagement(ctx, "$message", rng)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get campaign engagement: %w", err)
+			}
+		case ChannelSMS:
+			smsEngagement, err = s.getCampaignSMSEngagement(ctx, rng, campaigns)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get campaign sms engagement: %w", err)
+			}
+		}
+	}
+
+	// A/B test variations are only reported for email campaigns.
+	variationTests := VariationTestsByCampaignID{}
+	if channel != ChannelSMS {
+		var err error
+		variationTests, err = s.getVariationTests(ctx, rng)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get campaign variations: %w", err)
+		}
+	}
+
+	contents, err := s.getCampaignContents(ctx, campaigns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get campaign contents: %w", err)
+	}
+
+	audiences, err := s.getCampaignAudiences(ctx, campaigns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get campaign audiences: %w", err)
+	}
+
+	// Recipients are the Received Email (or Sent SMS) events of the campaign,
+	// falling back to Klaviyo's estimate for campaigns without any.
+	recipients := map[string]campaignRecipients{}
+	estimateCampaigns := []reportCampaign{}
+	for _, campaign := range campaigns {
+		switch {
+		case engagement[campaign.ID].Received > 0:
+			recipients[campaign.ID] = campaignRecipients{Count: engagement[campaign.ID].Received, Source: RecipientSourceReceived}
+		case smsEngagement[campaign.ID] != nil && smsEngagement[campaign.ID].Sent > 0:
+			recipients[campaign.ID] = campaignRecipients{Count: smsEngagement[campaign.ID].Sent, Source: RecipientSourceSent}
+		default:
+			estimateCampaigns = append(estimateCampaigns, campaign)
+		}
+	}
+
+	recipientCounts, err := s.getCampaignRecipientCounts(ctx, estimateCampaigns)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, campaign := range estimateCampaigns {
+		recipients[campaign.ID] = campaignRecipients{Count: recipientCounts[i], Source: RecipientSourceEstimate}
+	}
+
+	templateCampaigns := []KlaviyoReportTemplateCampaign{}
+	for _, campaign := range campaigns {
+		// Campaigns without any attributed orders have no metrics but are
+		// still reported.
+		metric := metrics[campaign.ID]
+
+		templateCampaign := calculateCampaign(campaign.Name, metric, recipients[campaign.ID], engagement[campaign.ID])
+		templateCampaign.OrderStatus = campaignOrderStatus(metric, recipients[campaign.ID])
+		templateCampaign.Channel = campaign.Channel
+		templateCampaign.Tags = campaign.Tags
+		templateCampaign.SendTime = campaign.SendTime
+		templateCampaign.Subject = contents[campaign.ID].Subject
+		templateCampaign.PreviewText = contents[campaign.ID].PreviewText
+		templateCampaign.Audiences = audiences[campaign.ID]
+		templateCampaign.SMS = smsEngagement[campaign.ID]
+		if test, ok := variationTests[campaign.ID]; ok {
+			templateCampaign.VariationTest = &test
+		}
 		templateCampaigns = append(templateCampaigns, templateCampaign)
 	}
 
 	return templateCampaigns, nil
 }
 
-func calculateCampaign(name string, metric Metric, recipientCount int) KlaviyoReportTemplateCampaign {
+// getCampaignRecipientCounts fetches the recipient estimation of each campaign
+// concurrently, returning the counts in the same order as campaigns.
+func (s *Service) getCampaignRecipientCounts(ctx context.Context, campaigns []reportCampaign) ([]int, error) {
+	recipientCounts := make([]int, len(campaigns))
+
+	err := forEach(ctx, len(campaigns), s.klaviyoConcurrency(), func(ctx context.Context, i int) error {
+		campaignID := campaigns[i].ID
+		params := &klaviyo.GetCampaignRecipientEstimationParams{
+			Revision: "2023-12-15",
+		}
+		res, err := cacheResponse(ctx, s, "recipient-estimation", []any{campaignID, params}, func() (*klaviyo.GetCampaignRecipientEstimationRes, error) {
+			res, err := s.klaviyo(ctx).GetCampaignRecipientEstimationWithResponse(ctx, campaignID, params)
+			if err != nil {
+				return nil, err
+			}
+			return klaviyoclient.CheckResponse(res.StatusCode(), res.Body, res.JSON200)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get campaign recipient estimation: %w", err)
+		}
+
+		recipientCounts[i] = res.Data.Attributes.EstimatedRecipientCount
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return recipientCounts, nil
+}
+
+func calculateCampaign(name string, metric Metric, recipients campaignRecipients, engagement Engagement) KlaviyoReportTemplateCampaign {
+	recipientCount := recipients.Count
+
 	campaign := KlaviyoReportTemplateCampaign{}
 	campaign.Name = name
+	campaign.Engagement = engagement
 	campaign.TotalRecipients = recipientCount
+	campaign.RecipientSource = recipients.Source
 	campaign.OrdersPlaced = metric.Count
+	campaign.Revenue = metric.Revenue
 
-	if metric.Count == 0 || metric.Revenue == 0 || recipientCount == 0 {
+	if metric.Count == 0 || recipientCount == 0 {
 		return campaign
 	}
 
 	campaign.ConversionRate = float64(metric.Count) / float64(recipientCount)
-	campaign.ConversionValue = metric.Revenue / float64(metric.Count)
-	campaign.RevenuePerRecipient = metric.Revenue / float64(recipientCount)
+
+	// Conversion metrics without a value, e.g. Started Checkout, still have a
+	// conversion rate.
+	if metric.Revenue != 0 {
+		campaign.ConversionValue = metric.Revenue / float64(metric.Count)
+		campaign.RevenuePerRecipient = metric.Revenue / float64(recipientCount)
+	}
 
 	return campaign
 }
+
+func calculateTotals(campaigns []KlaviyoReportTemplateCampaign) KlaviyoReportTotals {
+	totals := KlaviyoReportTotals{}
+	for _, campaign := range campaigns {
+		totals.TotalRecipients += campaign.TotalRecipients
+		totals.OrdersPlaced += campaign.OrdersPlaced
+		totals.Revenue += campaign.Revenue
+		totals.Engagement = totals.Engagement.Add(campaign.Engagement)
+	}
+
+	if totals.OrdersPlaced > 0 {
+		totals.ConversionValue = totals.Revenue / float64(totals.OrdersPlaced)
+	}
+
+	if totals.TotalRecipients > 0 {
+		totals.ConversionRate = float64(totals.OrdersPlaced) / float64(totals.TotalRecipients)
+		totals.RevenuePerRecipient = totals.Revenue / float64(totals.TotalRecipients)
+	}
+
+	return totals
+}
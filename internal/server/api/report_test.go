@@ -0,0 +1,67 @@
+package api
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalculateCampaign(t *testing.T) {
+	tests := []struct {
+		name                    string
+		metric                  Metric
+		recipients              int
+		wantConversionRate      float64
+		wantConversionValue     float64
+		wantRevenuePerRecipient float64
+	}{
+		{name: "orders", metric: Metric{Count: 10, Revenue: 500}, recipients: 1000, wantConversionRate: 0.01, wantConversionValue: 50, wantRevenuePerRecipient: 0.5},
+		{name: "metric without a value", metric: Metric{Count: 10}, recipients: 1000, wantConversionRate: 0.01},
+		{name: "no orders", metric: Metric{}, recipients: 1000},
+		{name: "no recipients", metric: Metric{Count: 10, Revenue: 500}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calculateCampaign("Campaign", tt.metric, campaignRecipients{Count: tt.recipients, Source: RecipientSourceReceived}, Engagement{})
+			if got.OrdersPlaced != tt.metric.Count || got.Revenue != tt.metric.Revenue || got.TotalRecipients != tt.recipients {
+				t.Errorf("got %d orders, %v revenue, %d recipients", got.OrdersPlaced, got.Revenue, got.TotalRecipients)
+			}
+			if !floatEqual(got.ConversionRate, tt.wantConversionRate) {
+				t.Errorf("ConversionRate = %v, want %v", got.ConversionRate, tt.wantConversionRate)
+			}
+			if !floatEqual(got.ConversionValue, tt.wantConversionValue) {
+				t.Errorf("ConversionValue = %v, want %v", got.ConversionValue, tt.wantConversionValue)
+			}
+			if !floatEqual(got.RevenuePerRecipient, tt.wantRevenuePerRecipient) {
+				t.Errorf("RevenuePerRecipient = %v, want %v", got.RevenuePerRecipient, tt.wantRevenuePerRecipient)
+			}
+		})
+	}
+}
+
+func TestCampaignOrderStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		metric     Metric
+		recipients campaignRecipients
+		want       string
+	}{
+		{name: "orders", metric: Metric{Count: 1}, recipients: campaignRecipients{Count: 100, Source: RecipientSourceReceived}, want: OrderStatusAttributed},
+		{name: "orders with estimated recipients", metric: Metric{Count: 1}, recipients: campaignRecipients{Count: 100, Source: RecipientSourceEstimate}, want: OrderStatusAttributed},
+		{name: "delivered without orders", recipients: campaignRecipients{Count: 100, Source: RecipientSourceReceived}, want: OrderStatusNoOrders},
+		{name: "sms without orders", recipients: campaignRecipients{Count: 100, Source: RecipientSourceSent}, want: OrderStatusNoOrders},
+		{name: "no events", recipients: campaignRecipients{Count: 100, Source: RecipientSourceEstimate}, want: OrderStatusDataMissing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := campaignOrderStatus(tt.metric, tt.recipients); got != tt.want {
+				t.Errorf("campaignOrderStatus() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func floatEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
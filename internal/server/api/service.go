@@ -7,18 +7,22 @@ import (
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"github.com/oliverbenns/klaviyo-report/generated/klaviyo"
+	"github.com/oliverbenns/klaviyo-report/internal/accounts"
+	"github.com/oliverbenns/klaviyo-report/internal/klaviyoclient"
 	redis "github.com/redis/go-redis/v9"
 	sloggin "github.com/samber/slog-gin"
 )
 
 type Service struct {
-	RedisClient   *redis.Client
-	Port          int
-	Logger        *slog.Logger
-	AppURL        string
-	ApiKey        string
-	KlaviyoClient *klaviyo.ClientWithResponses
+	RedisClient    *redis.Client
+	Port           int
+	Logger         *slog.Logger
+	AppURL         string
+	ApiKey         string
+	Accounts       accounts.Registry
+	KlaviyoClients *klaviyoclient.Pool
+	// KlaviyoConcurrency limits concurrent Klaviyo requests per report.
+	KlaviyoConcurrency int
 }
 
 func (s *Service) Run(ctx context.Context) error {
@@ -35,6 +39,7 @@ func (s *Service) Run(ctx context.Context) error {
 
 	router.GET("/", s.GetHome)
 	router.GET("/reports/:klaviyo_account_id", s.GetKlaviyoReport)
+	router.GET("/api/v1/reports/:klaviyo_account_id", s.GetKlaviyoReportJSON)
 
 	router.GET("/ping", s.GetPing)
 
@@ -54,6 +59,13 @@ func (s *Service) middleware(c *gin.Context) {
 	c.Next()
 }
 
+func (s *Service) klaviyoConcurrency() int {
+	if s.KlaviyoConcurrency < 1 {
+		return defaultKlaviyoConcurrency
+	}
+	return s.KlaviyoConcurrency
+}
+
 func (s *Service) GetPing(c *gin.Context) {
 	c.PureJSON(200, gin.H{
 		"message": "pong",
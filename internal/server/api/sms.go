@@ -0,0 +1,336 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oliverbenns/klaviyo-report/generated/klaviyo"
+	"github.com/oliverbenns/klaviyo-report/internal/conv"
+	"github.com/oliverbenns/klaviyo-report/internal/klaviyoclient"
+)
+
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+	ChannelAll   = "all"
+)
+
+var reportChannels = []string{ChannelEmail, ChannelSMS, ChannelAll}
+
+// parseReportChannel reads which campaign channel to report on, defaulting
+// to email.
+func parseReportChannel(c *gin.Context) (string, error) {
+	channel := c.DefaultQuery("channel", ChannelEmail)
+	for _, option := range reportChannels {
+		if option == channel {
+			return channel, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown channel %q, must be one of %s", channel, strings.Join(reportChannels, ", "))
+}
+
+// campaignChannels returns the Klaviyo message channels of a report channel.
+// Klaviyo requires campaigns to be listed one channel at a time.
+func campaignChannels(channel string) []string {
+	if channel == ChannelAll {
+		return []string{ChannelEmail, ChannelSMS}
+	}
+	return []string{channel}
+}
+
+// SMSEngagement is the funnel of an SMS campaign. Rates are relative to the
+// sent count.
+type SMSEngagement struct {
+	Sent            int     `json:"sent"`
+	Delivered       int     `json:"delivered"`
+	Clicked         int     `json:"clicked"`
+	Failed          int     `json:"failed"`
+	Unsubscribed    int     `json:"unsubscribed"`
+	DeliveryRate    float64 `json:"delivery_rate"`
+	ClickRate       float64 `json:"click_rate"`
+	FailureRate     float64 `json:"failure_rate"`
+	UnsubscribeRate float64 `json:"unsubscribe_rate"`
+	// Sends by To Phone Region, e.g. US.
+	Regions map[string]int `json:"regions,omitempty"`
+	// Failures by Failure Type.
+	FailureTypes map[string]int `json:"failure_types,omitempty"`
+	// Segments is the number of segments the message body is split into.
+	Segments int `json:"segments"`
+	// Cost is the cost of sending, using the account's sms_segment_costs.
+	Cost        float64 `json:"cost"`
+	CostPerSend float64 `json:"cost_per_send"`
+	HasCost     bool    `json:"has_cost"`
+}
+
+type SMSEngagementByID map[string]*SMSEngagement
+
+// smsMetrics are the Klaviyo metrics making up the SMS funnel. Sends are
+// also grouped by region and failures by type. They are not grouped by
+// $campaign_channel as these metrics only exist for SMS, so it would always
+// be "sms", and campaigns are already listed per channel.
+var smsMetrics = []struct {
+	Name      string
+	Dimension string
+	Count     func(e *SMSEngagement) *int
+}{
+	{"Sent SMS", "To Phone Region", func(e *SMSEngagement) *int { return &e.Sent }},
+	{"Received SMS", "", func(e *SMSEngagement) *int { return &e.Delivered }},
+	{"Clicked SMS", "", func(e *SMSEngagement) *int { return &e.Clicked }},
+	{"Failed to Deliver SMS", "Failure Type", func(e *SMSEngagement) *int { return &e.Failed }},
+	{"Unsubscribed from SMS", "", func(e *SMSEngagement) *int { return &e.Unsubscribed }},
+}
+
+// getSMSEngagement returns the SMS funnel of each message, with sends broken
+// down by To Phone Region and failures by Failure Type. Metrics the account
+// has never recorded, e.g. no SMS has failed yet, are counted as zero.
+func (s *Service) getSMSEngagement(ctx context.Context, rng ReportRange) (SMSEngagementByID, error) {
+	names := make([]string, len(smsMetrics))
+	for i, metric := range smsMetrics {
+		names[i] = metric.Name
+	}
+
+	metricIDs, err := s.getKlaviyoMetricIDs(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([][]klaviyo.MetricAggregateRowDTO, len(smsMetrics))
+	err = forEach(ctx, len(smsMetrics), s.klaviyoConcurrency(), func(ctx context.Context, i int) error {
+		if metricIDs[i] == "" {
+			return nil
+		}
+
+		metric := smsMetrics[i]
+		by := []string{"$message"}
+		if metric.Dimension != "" {
+			by = append(by, metric.Dimension)
+		}
+
+		rows, _, err := s.queryMetricAggregates(ctx, metricIDs[i], []klaviyo.MetricAggregateQueryResourceObjectAttributesMeasurements{
+			"count",
+		}, by, "month", rng)
+		if err != nil {
+			return fmt.Errorf("failed to get %s counts: %w", metric.Name, err)
+		}
+
+		results[i] = rows
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	engagement := SMSEngagementByID{}
+	for i, metric := range smsMetrics {
+		for _, row := range results[i] {
+			count, err := sumMeasurement(row.Measurements["count"])
+			if err != nil {
+				return nil, fmt.Errorf("failed to sum metric count aggregate measurements: %w", err)
+			}
+
+			e, ok := engagement[row.Dimensions[0]]
+			if !ok {
+				e = &SMSEngagement{}
+				engagement[row.Dimensions[0]] = e
+			}
+
+			// Rows are split by the extra dimension so are added up.
+			*metric.Count(e) += int(count)
+
+			if len(row.Dimensions) < 2 || row.Dimensions[1] == "" {
+				continue
+			}
+			switch metric.Dimension {
+			case "To Phone Region":
+				if e.Regions == nil {
+					e.Regions = map[string]int{}
+				}
+				e.Regions[row.Dimensions[1]] += int(count)
+			case "Failure Type":
+				if e.FailureTypes == nil {
+					e.FailureTypes = map[string]int{}
+				}
+				e.FailureTypes[row.Dimensions[1]] += int(count)
+			}
+		}
+	}
+
+	return engagement, nil
+}
+
+// calculateSMSEngagement derives the rates and, when the account has segment
+// costs configured, the cost of sending the campaign. Costs are looked up by
+// To Phone Region, falling back to the "*" cost.
+func calculateSMSEngagement(e SMSEngagement, segments int, segmentCosts map[string]float64) SMSEngagement {
+	e.Segments = segments
+
+	if e.Sent > 0 {
+		sent := float64(e.Sent)
+		e.DeliveryRate = float64(e.Delivered) / sent
+		e.ClickRate = float64(e.Clicked) / sent
+		e.FailureRate = float64(e.Failed) / sent
+		e.UnsubscribeRate = float64(e.Unsubscribed) / sent
+	}
+
+	if len(segmentCosts) == 0 || segments == 0 {
+		return e
+	}
+
+	regions := e.Regions
+	if len(regions) == 0 {
+		regions = map[string]int{"": e.Sent}
+	}
+
+	e.Cost = 0
+	for region, sent := range regions {
+		cost, ok := segmentCosts[region]
+		if !ok {
+			cost = segmentCosts["*"]
+		}
+		e.Cost += float64(sent*segments) * cost
+	}
+	e.HasCost = true
+
+	if e.Sent > 0 {
+		e.CostPerSend = e.Cost / float64(e.Sent)
+	}
+
+	return e
+}
+
+// getCampaignSMSEngagement returns the SMS funnel and cost of each SMS
+// campaign.
+func (s *Service) getCampaignSMSEngagement(ctx context.Context, rng ReportRange, campaigns []reportCampaign) (SMSEngagementByID, error) {
+	smsCampaigns := []reportCampaign{}
+	for _, campaign := range campaigns {
+		if campaign.Channel == ChannelSMS {
+			smsCampaigns = append(smsCampaigns, campaign)
+		}
+	}
+
+	// Accounts without SMS campaigns may have no SMS metrics at all.
+	if len(smsCampaigns) == 0 {
+		return SMSEngagementByID{}, nil
+	}
+
+	engagement, err := s.getSMSEngagement(ctx, rng)
+	if err != nil {
+		return nil, err
+	}
+
+	segments := make([]int, len(smsCampaigns))
+	err = forEach(ctx, len(smsCampaigns), s.klaviyoConcurrency(), func(ctx context.Context, i int) error {
+		campaignSegments, err := s.getSMSSegments(ctx, smsCampaigns[i].ID)
+		if err != nil {
+			return err
+		}
+
+		segments[i] = campaignSegments
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	segmentCosts := s.account(ctx).SMSSegmentCosts
+	campaignEngagement := SMSEngagementByID{}
+	for i, campaign := range smsCampaigns {
+		e := SMSEngagement{}
+		if engagement[campaign.ID] != nil {
+			e = *engagement[campaign.ID]
+		}
+
+		e = calculateSMSEngagement(e, segments[i], segmentCosts)
+		campaignEngagement[campaign.ID] = &e
+	}
+
+	return campaignEngagement, nil
+}
+
+// getSMSSegments returns the number of segments the SMS campaign's message is
+// sent as.
+func (s *Service) getSMSSegments(ctx context.Context, campaignID string) (int, error) {
+	res, err := s.getCampaignMessages(ctx, campaignID)
+	if err != nil {
+		return 0, err
+	}
+
+	segments := 0
+	for _, message := range res.Data {
+		if message.Attributes.Channel != ChannelSMS {
+			continue
+		}
+
+		content, err := message.Attributes.Content.AsSMSContentSubObject()
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse sms content: %w", err)
+		}
+		segments = max(segments, smsSegments(conv.Val(content.Body)))
+	}
+
+	return segments, nil
+}
+
+func (s *Service) getCampaignMessages(ctx context.Context, campaignID string) (*klaviyo.GetCampaignMessageResponseCollectionCompoundDocument, error) {
+	params := &klaviyo.GetCampaignCampaignMessagesParams{
+		Revision: "2023-12-15",
+	}
+	res, err := cacheResponse(ctx, s, "campaign-messages", []any{campaignID, params}, func() (*klaviyo.GetCampaignMessageResponseCollectionCompoundDocument, error) {
+		res, err := s.klaviyo(ctx).GetCampaignCampaignMessagesWithResponse(ctx, campaignID, params)
+		if err != nil {
+			return nil, err
+		}
+		return klaviyoclient.CheckResponse(res.StatusCode(), res.Body, res.JSON200)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get campaign messages: %w", err)
+	}
+
+	return res, nil
+}
+
+// gsm7 is the GSM 03.38 basic character set. Extension characters count as
+// two.
+const (
+	gsm7Basic     = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+	gsm7Extension = "^{}\\[~]|€\f"
+)
+
+// smsSegments returns how many segments an SMS body is sent as. Bodies using
+// only GSM-7 characters fit 160 characters in one segment (153 when split),
+// anything else is sent as UCS-2 with 70 (67 when split).
+func smsSegments(body string) int {
+	if body == "" {
+		return 0
+	}
+
+	gsmLength := 0
+	isGSM := true
+	for _, r := range body {
+		switch {
+		case strings.ContainsRune(gsm7Basic, r):
+			gsmLength++
+		case strings.ContainsRune(gsm7Extension, r):
+			gsmLength += 2
+		default:
+			isGSM = false
+		}
+	}
+
+	if isGSM {
+		return segmentCount(gsmLength, 160, 153)
+	}
+	return segmentCount(len(utf16.Encode([]rune(body))), 70, 67)
+}
+
+func segmentCount(length, single, multi int) int {
+	if length <= single {
+		return 1
+	}
+	return int(math.Ceil(float64(length) / float64(multi)))
+}
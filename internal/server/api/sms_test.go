@@ -0,0 +1,112 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSMSSegments(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"empty", "", 0},
+		{"short", "Hello, 20% off today!", 1},
+		{"gsm single segment", strings.Repeat("a", 160), 1},
+		{"gsm two segments", strings.Repeat("a", 161), 2},
+		{"gsm two full segments", strings.Repeat("a", 306), 2},
+		{"gsm three segments", strings.Repeat("a", 307), 3},
+		{"gsm extension characters count twice", strings.Repeat("a", 158) + "€", 1},
+		{"gsm extension character over the limit", strings.Repeat("a", 159) + "€", 2},
+		{"ucs-2 single segment", strings.Repeat("ą", 70), 1},
+		{"ucs-2 two segments", strings.Repeat("ą", 71), 2},
+		{"ucs-2 three segments", strings.Repeat("ą", 135), 3},
+		{"emoji are two ucs-2 characters", strings.Repeat("a", 68) + "👋", 1},
+		{"emoji over the limit", strings.Repeat("a", 69) + "👋", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := smsSegments(tt.body)
+			if got != tt.want {
+				t.Errorf("smsSegments() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateSMSEngagement(t *testing.T) {
+	tests := []struct {
+		name            string
+		engagement      SMSEngagement
+		segments        int
+		segmentCosts    map[string]float64
+		wantCost        float64
+		wantCostPerSend float64
+		wantHasCost     bool
+	}{
+		{
+			name:       "no segment costs",
+			engagement: SMSEngagement{Sent: 100},
+			segments:   1,
+		},
+		{
+			name:            "costs by region with a fallback",
+			engagement:      SMSEngagement{Sent: 100, Regions: map[string]int{"US": 80, "CA": 20}},
+			segments:        2,
+			segmentCosts:    map[string]float64{"US": 0.01, "*": 0.02},
+			wantCost:        2.4,
+			wantCostPerSend: 0.024,
+			wantHasCost:     true,
+		},
+		{
+			name:            "no regions uses the fallback",
+			engagement:      SMSEngagement{Sent: 50},
+			segments:        1,
+			segmentCosts:    map[string]float64{"US": 0.01, "*": 0.02},
+			wantCost:        1,
+			wantCostPerSend: 0.02,
+			wantHasCost:     true,
+		},
+		{
+			name:         "no message body",
+			engagement:   SMSEngagement{Sent: 50},
+			segmentCosts: map[string]float64{"*": 0.02},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calculateSMSEngagement(tt.engagement, tt.segments, tt.segmentCosts)
+			if got.HasCost != tt.wantHasCost || !floatEqual(got.Cost, tt.wantCost) || !floatEqual(got.CostPerSend, tt.wantCostPerSend) {
+				t.Errorf("cost = %v %v %v, want %v %v %v", got.HasCost, got.Cost, got.CostPerSend, tt.wantHasCost, tt.wantCost, tt.wantCostPerSend)
+			}
+		})
+	}
+}
+
+func TestCalculateSMSEngagementRates(t *testing.T) {
+	got := calculateSMSEngagement(SMSEngagement{Sent: 200, Delivered: 190, Clicked: 20, Failed: 10, Unsubscribed: 2}, 1, nil)
+
+	rates := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"delivery", got.DeliveryRate, 0.95},
+		{"click", got.ClickRate, 0.1},
+		{"failure", got.FailureRate, 0.05},
+		{"unsubscribe", got.UnsubscribeRate, 0.01},
+	}
+	for _, rate := range rates {
+		if !floatEqual(rate.got, rate.want) {
+			t.Errorf("%s rate = %v, want %v", rate.name, rate.got, rate.want)
+		}
+	}
+
+	empty := calculateSMSEngagement(SMSEngagement{}, 0, nil)
+	if empty.DeliveryRate != 0 || empty.ClickRate != 0 {
+		t.Errorf("rates without sends = %+v, want 0", empty)
+	}
+}
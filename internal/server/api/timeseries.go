@@ -0,0 +1,160 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oliverbenns/klaviyo-report/generated/klaviyo"
+)
+
+var timeSeriesIntervals = []string{"day", "week", "month"}
+
+type TimeSeriesPoint struct {
+	Date         time.Time `json:"date"`
+	OrdersPlaced int       `json:"orders_placed"`
+	Revenue      float64   `json:"revenue"`
+}
+
+type ReportTimeSeries struct {
+	Interval string            `json:"interval"`
+	Points   []TimeSeriesPoint `json:"points"`
+}
+
+// parseTimeSeriesInterval reads the optional time series interval from the
+// query string. An empty interval means no time series.
+func parseTimeSeriesInterval(c *gin.Context) (string, error) {
+	interval := c.Query("interval")
+	if interval == "" {
+		return "", nil
+	}
+
+	for _, option := range timeSeriesIntervals {
+		if option == interval {
+			return interval, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown interval %q, must be one of %s", interval, strings.Join(timeSeriesIntervals, ", "))
+}
+
+// getTimeSeries returns the attributed conversion metric count and revenue per
+// interval bucket, summed over every message.
+func (s *Service) getTimeSeries(ctx context.Context, interval string, rng ReportRange) (*ReportTimeSeries, error) {
+	conversionMetric, err := s.getConversionMetric(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	aggResults, dates, err := s.queryMetricAggregates(ctx, conversionMetric.ID, []klaviyo.MetricAggregateQueryResourceObjectAttributesMeasurements{
+		"count",
+		"sum_value",
+	}, []string{"$attributed_message"}, interval, rng)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]TimeSeriesPoint, len(dates))
+	for i, date := range dates {
+		points[i].Date = date.In(rng.From.Location())
+	}
+
+	for _, aggResult := range aggResults {
+		// Unattributed events have no message.
+		if len(aggResult.Dimensions) == 0 || aggResult.Dimensions[0] == "" {
+			continue
+		}
+
+		counts, err := measurementValues(aggResult.Measurements["count"])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read metric count aggregate measurements: %w", err)
+		}
+
+		revenues, err := measurementValues(aggResult.Measurements["sum_value"])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read metric sum_value aggregate measurements: %w", err)
+		}
+
+		for i := range points {
+			if i < len(counts) {
+				points[i].OrdersPlaced += int(counts[i])
+			}
+			if i < len(revenues) {
+				points[i].Revenue += revenues[i]
+			}
+		}
+	}
+
+	return &ReportTimeSeries{
+		Interval: interval,
+		Points:   points,
+	}, nil
+}
+
+const (
+	timeSeriesChartWidth   = 800.
+	timeSeriesChartHeight  = 200.
+	timeSeriesChartPadding = 20.
+)
+
+// timeSeriesChart renders revenue and orders as an inline SVG line chart.
+// Each line is scaled to its own maximum so both are readable.
+func timeSeriesChart(series *ReportTimeSeries) template.HTML {
+	if series == nil || len(series.Points) == 0 {
+		return ""
+	}
+
+	maxRevenue, maxOrders := 0., 0.
+	for _, point := range series.Points {
+		maxRevenue = max(maxRevenue, point.Revenue)
+		maxOrders = max(maxOrders, float64(point.OrdersPlaced))
+	}
+
+	revenue := make([]float64, len(series.Points))
+	orders := make([]float64, len(series.Points))
+	for i, point := range series.Points {
+		revenue[i] = point.Revenue
+		orders[i] = float64(point.OrdersPlaced)
+	}
+
+	sb := strings.Builder{}
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %.0f %.0f" width="100%%" role="img" aria-label="Revenue and orders per %s">`,
+		timeSeriesChartWidth, timeSeriesChartHeight, series.Interval)
+	fmt.Fprintf(&sb, `<line x1="%.0f" y1="%.0f" x2="%.0f" y2="%.0f" stroke="#ccc" />`,
+		timeSeriesChartPadding, timeSeriesChartHeight-timeSeriesChartPadding, timeSeriesChartWidth-timeSeriesChartPadding, timeSeriesChartHeight-timeSeriesChartPadding)
+	fmt.Fprintf(&sb, `<polyline fill="none" stroke="#2961a0" stroke-width="2" points="%s"><title>Revenue</title></polyline>`, timeSeriesChartPoints(revenue, maxRevenue))
+	fmt.Fprintf(&sb, `<polyline fill="none" stroke="#e0892b" stroke-width="2" stroke-dasharray="4 3" points="%s"><title>Orders</title></polyline>`, timeSeriesChartPoints(orders, maxOrders))
+	fmt.Fprintf(&sb, `<text x="%.0f" y="%.0f" font-size="12" fill="#777">%s</text>`,
+		timeSeriesChartPadding, timeSeriesChartHeight-4, series.Points[0].Date.Format(dateLayout))
+	fmt.Fprintf(&sb, `<text x="%.0f" y="%.0f" font-size="12" fill="#777" text-anchor="end">%s</text>`,
+		timeSeriesChartWidth-timeSeriesChartPadding, timeSeriesChartHeight-4, series.Points[len(series.Points)-1].Date.Format(dateLayout))
+	sb.WriteString(`</svg>`)
+
+	// Only numbers and formatted dates are interpolated.
+	return template.HTML(sb.String())
+}
+
+func timeSeriesChartPoints(values []float64, maxValue float64) string {
+	width := timeSeriesChartWidth - 2*timeSeriesChartPadding
+	height := timeSeriesChartHeight - 2*timeSeriesChartPadding
+
+	points := make([]string, len(values))
+	for i, value := range values {
+		x := timeSeriesChartPadding + width/2
+		if len(values) > 1 {
+			x = timeSeriesChartPadding + width*float64(i)/float64(len(values)-1)
+		}
+
+		y := timeSeriesChartPadding + height
+		if maxValue > 0 {
+			y -= height * value / maxValue
+		}
+
+		points[i] = fmt.Sprintf("%.1f,%.1f", x, y)
+	}
+
+	return strings.Join(points, " ")
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/oliverbenns/klaviyo-report/generated/klaviyo"
+	"github.com/oliverbenns/klaviyo-report/internal/conv"
+)
+
+type KlaviyoReportTemplateVariation struct {
+	ID                  string  `json:"id"`
+	Subject             string  `json:"subject"`
+	TotalRecipients     int     `json:"total_recipients"`
+	OrdersPlaced        int     `json:"orders_placed"`
+	Revenue             float64 `json:"revenue"`
+	ConversionRate      float64 `json:"conversion_rate"`
+	RevenuePerRecipient float64 `json:"revenue_per_recipient"`
+	Winner              bool    `json:"winner"`
+}
+
+// VariationTest is the A/B test breakdown of a campaign. The winner is the
+// variation with the highest conversion rate and Confidence is 1 - p of a
+// two sided test that its rate differs from the runner up's, not that it is
+// better.
+type VariationTest struct {
+	Variations []KlaviyoReportTemplateVariation `json:"variations"`
+	Winner     string                           `json:"winner,omitempty"`
+	Confidence float64                          `json:"confidence"`
+}
+
+type VariationTestsByCampaignID map[string]VariationTest
+
+// getVariationTests returns the A/B test breakdown of each campaign sent with
+// more than one variation. Accounts that have never sent an email have none.
+func (s *Service) getVariationTests(ctx context.Context, rng ReportRange) (VariationTestsByCampaignID, error) {
+	conversionMetric, err := s.getConversionMetric(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	receivedEmailMetricID, err := s.getMetricID(ctx, conv.Ptr("equals(integration.name,'Klaviyo')"), "Received Email")
+	if errors.Is(err, errMetricNotFound) {
+		return VariationTestsByCampaignID{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	conversions, _, err := s.queryMetricAggregates(ctx, conversionMetric.ID, []klaviyo.MetricAggregateQueryResourceObjectAttributesMeasurements{
+		"count",
+		"sum_value",
+	}, []string{"$attributed_message", "$attributed_variation"}, "month", rng)
+	if err != nil {
+		return nil, err
+	}
+
+	received, _, err := s.queryMetricAggregates(ctx, receivedEmailMetricID, []klaviyo.MetricAggregateQueryResourceObjectAttributesMeasurements{
+		"count",
+	}, []string{"$message", "$variation", "Subject"}, "month", rng)
+	if err != nil {
+		return nil, err
+	}
+
+	variations := map[string]map[string]*KlaviyoReportTemplateVariation{}
+	variation := func(dimensions []string) *KlaviyoReportTemplateVariation {
+		campaignID, variationID := dimensions[0], dimensions[1]
+		if variations[campaignID] == nil {
+			variations[campaignID] = map[string]*KlaviyoReportTemplateVariation{}
+		}
+		if variations[campaignID][variationID] == nil {
+			variations[campaignID][variationID] = &KlaviyoReportTemplateVariation{ID: variationID}
+		}
+		return variations[campaignID][variationID]
+	}
+
+	for _, aggResult := range received {
+		if len(aggResult.Dimensions) < 2 || aggResult.Dimensions[1] == "" {
+			continue
+		}
+
+		count, err := sumMeasurement(aggResult.Measurements["count"])
+		if err != nil {
+			return nil, fmt.Errorf("failed to sum metric count aggregate measurements: %w", err)
+		}
+
+		// Rows are split by subject, which is the same for all sends of a
+		// variation unless it was edited mid send.
+		v := variation(aggResult.Dimensions)
+		v.TotalRecipients += int(count)
+		if v.Subject == "" && len(aggResult.Dimensions) > 2 {
+			v.Subject = aggResult.Dimensions[2]
+		}
+	}
+
+	for _, aggResult := range conversions {
+		if len(aggResult.Dimensions) < 2 || aggResult.Dimensions[1] == "" {
+			continue
+		}
+
+		count, err := sumMeasurement(aggResult.Measurements["count"])
+		if err != nil {
+			return nil, fmt.Errorf("failed to sum metric count aggregate measurements: %w", err)
+		}
+		revenue, err := sumMeasurement(aggResult.Measurements["sum_value"])
+		if err != nil {
+			return nil, fmt.Errorf("failed to sum metric sum_value aggregate measurements: %w", err)
+		}
+
+		v := variation(aggResult.Dimensions)
+		v.OrdersPlaced = int(count)
+		v.Revenue = revenue
+	}
+
+	tests := VariationTestsByCampaignID{}
+	for campaignID, campaignVariations := range variations {
+		if len(campaignVariations) < 2 {
+			continue
+		}
+
+		test := VariationTest{}
+		for _, v := range campaignVariations {
+			test.Variations = append(test.Variations, calculateVariation(*v))
+		}
+		tests[campaignID] = calculateVariationTest(test)
+	}
+
+	return tests, nil
+}
+
+func calculateVariation(variation KlaviyoReportTemplateVariation) KlaviyoReportTemplateVariation {
+	if variation.TotalRecipients == 0 {
+		return variation
+	}
+
+	variation.ConversionRate = float64(variation.OrdersPlaced) / float64(variation.TotalRecipients)
+	variation.RevenuePerRecipient = variation.Revenue / float64(variation.TotalRecipients)
+	return variation
+}
+
+// calculateVariationTest sorts the variations best first and marks the winner.
+// There is no winner when nothing converted or the top two are tied.
+func calculateVariationTest(test VariationTest) VariationTest {
+	sort.SliceStable(test.Variations, func(i, j int) bool {
+		a, b := test.Variations[i], test.Variations[j]
+		if a.ConversionRate != b.ConversionRate {
+			return a.ConversionRate > b.ConversionRate
+		}
+		return a.ID < b.ID
+	})
+
+	best, runnerUp := test.Variations[0], test.Variations[1]
+	if best.ConversionRate == 0 || best.ConversionRate == runnerUp.ConversionRate {
+		return test
+	}
+
+	test.Variations[0].Winner = true
+	test.Winner = best.ID
+	test.Confidence = twoProportionConfidence(best.OrdersPlaced, best.TotalRecipients, runnerUp.OrdersPlaced, runnerUp.TotalRecipients)
+	return test
+}
+
+// twoProportionConfidence returns 1 - p of a two sided two-proportion z-test,
+// i.e. the confidence that the conversion rates differ.
+func twoProportionConfidence(conversionsA, totalA, conversionsB, totalB int) float64 {
+	if totalA == 0 || totalB == 0 {
+		return 0
+	}
+
+	rateA := float64(conversionsA) / float64(totalA)
+	rateB := float64(conversionsB) / float64(totalB)
+	pooled := float64(conversionsA+conversionsB) / float64(totalA+totalB)
+
+	stdErr := math.Sqrt(pooled * (1 - pooled) * (1/float64(totalA) + 1/float64(totalB)))
+	if stdErr == 0 {
+		return 0
+	}
+
+	z := math.Abs(rateA-rateB) / stdErr
+	return math.Erf(z / math.Sqrt2)
+}
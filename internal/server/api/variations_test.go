@@ -0,0 +1,87 @@
+package api
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTwoProportionConfidence(t *testing.T) {
+	tests := []struct {
+		name                 string
+		conversionsA, totalA int
+		conversionsB, totalB int
+		want                 float64
+	}{
+		{name: "significant difference", conversionsA: 200, totalA: 1000, conversionsB: 150, totalB: 1000, want: 0.99674},
+		{name: "small sample", conversionsA: 12, totalA: 100, conversionsB: 10, totalB: 100, want: 0.34872},
+		{name: "order does not matter", conversionsA: 20, totalA: 1000, conversionsB: 30, totalB: 1000, want: 0.84792},
+		{name: "same rate", conversionsA: 10, totalA: 100, conversionsB: 20, totalB: 200, want: 0},
+		{name: "no conversions", conversionsA: 0, totalA: 100, conversionsB: 0, totalB: 100, want: 0},
+		{name: "no recipients", conversionsA: 0, totalA: 0, conversionsB: 5, totalB: 100, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := twoProportionConfidence(tt.conversionsA, tt.totalA, tt.conversionsB, tt.totalB)
+			if math.Abs(got-tt.want) > 1e-4 {
+				t.Errorf("twoProportionConfidence() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateVariationTest(t *testing.T) {
+	variation := func(id string, orders, recipients int) KlaviyoReportTemplateVariation {
+		return calculateVariation(KlaviyoReportTemplateVariation{ID: id, OrdersPlaced: orders, TotalRecipients: recipients})
+	}
+
+	tests := []struct {
+		name       string
+		variations []KlaviyoReportTemplateVariation
+		wantOrder  []string
+		wantWinner string
+	}{
+		{
+			name:       "best conversion rate wins",
+			variations: []KlaviyoReportTemplateVariation{variation("a", 150, 1000), variation("b", 200, 1000), variation("c", 100, 1000)},
+			wantOrder:  []string{"b", "a", "c"},
+			wantWinner: "b",
+		},
+		{
+			name:       "tie has no winner",
+			variations: []KlaviyoReportTemplateVariation{variation("b", 10, 100), variation("a", 10, 100)},
+			wantOrder:  []string{"a", "b"},
+		},
+		{
+			name:       "no conversions has no winner",
+			variations: []KlaviyoReportTemplateVariation{variation("a", 0, 100), variation("b", 0, 100)},
+			wantOrder:  []string{"a", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calculateVariationTest(VariationTest{Variations: tt.variations})
+			if got.Winner != tt.wantWinner {
+				t.Errorf("Winner = %q, want %q", got.Winner, tt.wantWinner)
+			}
+
+			for i, id := range tt.wantOrder {
+				if got.Variations[i].ID != id {
+					t.Fatalf("variation %d = %s, want %s", i, got.Variations[i].ID, id)
+				}
+				wantWinner := tt.wantWinner != "" && i == 0
+				if got.Variations[i].Winner != wantWinner {
+					t.Errorf("variation %s Winner = %t, want %t", id, got.Variations[i].Winner, wantWinner)
+				}
+			}
+
+			if tt.wantWinner == "" && got.Confidence != 0 {
+				t.Errorf("Confidence = %v without a winner", got.Confidence)
+			}
+			if tt.wantWinner != "" && got.Confidence <= 0 {
+				t.Errorf("Confidence = %v, want > 0", got.Confidence)
+			}
+		})
+	}
+}